@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/go-vela/vela-npm/internal/npm"
+)
+
+// commands creates the subcommands, each running its own plugin with the shared flags.
+func commands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:   npm.PublishAction,
+			Usage:  "publish the package, workspaces or prebuilt tarballs",
+			Action: run,
+			Flags: []cli.Flag{
+				tagFlag(),
+				auditLevelFlag(),
+				auditReportFlag(),
+				licenseAllowFlag(),
+				licenseDenyFlag(),
+				licenseModeFlag(),
+				lockfileCheckFlag(),
+				allowedRegistriesFlag(),
+				accessFlag(),
+				workspacesFlag(),
+				workspaceFlag(),
+				workspaceIncludeFlag(),
+				workspaceExcludeFlag(),
+				tarballFlag(),
+				skipExistingFlag(),
+				changedSinceFlag(),
+				changedTagPatternFlag(),
+				parallelismFlag(),
+			},
+		},
+		{
+			Name:   npm.VerifyAction,
+			Usage:  "run the pre-publish checks without publishing",
+			Action: run,
+			Flags: []cli.Flag{
+				auditLevelFlag(),
+				auditReportFlag(),
+				licenseAllowFlag(),
+				licenseDenyFlag(),
+				licenseModeFlag(),
+				lockfileCheckFlag(),
+				allowedRegistriesFlag(),
+				workspacesFlag(),
+				workspaceFlag(),
+				workspaceIncludeFlag(),
+				workspaceExcludeFlag(),
+				tarballFlag(),
+				skipExistingFlag(),
+				changedSinceFlag(),
+				changedTagPatternFlag(),
+				parallelismFlag(),
+			},
+		},
+		{
+			Name:   npm.PackAction,
+			Usage:  "create tarballs and a pack manifest without publishing",
+			Action: run,
+			Flags: []cli.Flag{
+				workspacesFlag(),
+				workspaceFlag(),
+				workspaceIncludeFlag(),
+				workspaceExcludeFlag(),
+				packDestinationFlag(),
+			},
+		},
+		{
+			Name:   npm.DistTagAction,
+			Usage:  "add or remove a dist-tag on a published version",
+			Action: run,
+			Flags: []cli.Flag{
+				packageFlag(),
+				tagFlag(),
+				removeFlag(),
+			},
+		},
+		{
+			Name:   npm.DeprecateAction,
+			Usage:  "deprecate published versions matching a semver range",
+			Action: run,
+			Flags: []cli.Flag{
+				packageFlag(),
+				workspacesFlag(),
+				workspaceFlag(),
+				workspaceIncludeFlag(),
+				workspaceExcludeFlag(),
+				versionRangeFlag(),
+				messageFlag(),
+			},
+		},
+		{
+			Name:   npm.UnpublishAction,
+			Usage:  "unpublish a single version of a package",
+			Action: run,
+			Flags: []cli.Flag{
+				packageFlag(),
+				confirmFlag(),
+			},
+		},
+		{
+			Name:   npm.AccessAction,
+			Usage:  "manage package visibility and team grants",
+			Action: run,
+			Flags: []cli.Flag{
+				packageFlag(),
+				workspacesFlag(),
+				workspaceFlag(),
+				workspaceIncludeFlag(),
+				workspaceExcludeFlag(),
+				accessFlag(),
+				grantsFlag(),
+			},
+		},
+		{
+			Name:   npm.InfoAction,
+			Usage:  "report the registry's dist-tags and versions of packages",
+			Action: run,
+			Flags: []cli.Flag{
+				packageFlag(),
+				workspacesFlag(),
+				workspaceFlag(),
+				workspaceIncludeFlag(),
+				workspaceExcludeFlag(),
+			},
+		},
+	}
+}
@@ -0,0 +1,588 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/go-vela/vela-npm/internal/npm"
+)
+
+// sharedFlags are the credential, registry and logging flags inherited by every subcommand.
+func sharedFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "token",
+			Aliases:     []string{"t"},
+			Usage:       "auth token",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_TOKEN"),
+				cli.EnvVar("PLUGIN_TOKEN"),
+				cli.EnvVar("NPM_TOKEN"),
+				cli.File("/vela/parameters/npm/token"),
+				cli.File("/vela/secrets/npm/token"),
+			),
+		},
+		&cli.StringFlag{
+			Name:        "username",
+			Aliases:     []string{"u"},
+			Usage:       "name of user",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_USERNAME"),
+				cli.EnvVar("PLUGIN_USERNAME"),
+				cli.EnvVar("NPM_USERNAME"),
+				cli.File("/vela/parameters/npm/username"),
+				cli.File("/vela/secrets/npm/username"),
+				cli.File("/vela/secrets/managed-auth/username"),
+			),
+		},
+		&cli.StringFlag{
+			Name:        "password",
+			Aliases:     []string{"p"},
+			Usage:       "password for user",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_PASSWORD"),
+				cli.EnvVar("PLUGIN_PASSWORD"),
+				cli.EnvVar("NPM_PASSWORD"),
+				cli.File("/vela/parameters/npm/password"),
+				cli.File("/vela/secrets/npm/password"),
+				cli.File("/vela/secrets/managed-auth/password"),
+			),
+		},
+		&cli.StringFlag{
+			Name:        "registry",
+			Aliases:     []string{"r"},
+			Usage:       "npm registry",
+			Value:       npm.DefaultRegistry,
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_REGISTRY"),
+				cli.EnvVar("PLUGIN_REGISTRY"),
+				cli.EnvVar("NPM_REGISTRY"),
+				cli.File("/vela/parameters/npm/registry"),
+				cli.File("/vela/secrets/npm/registry"),
+			),
+		},
+		&cli.StringSliceFlag{
+			Name:        "registry-tokens",
+			Usage:       "auth tokens for other registries in the form registry=token, registries without one use the token or username parameters",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_REGISTRY_TOKENS"),
+				cli.EnvVar("PLUGIN_REGISTRY_TOKENS"),
+				cli.EnvVar("NPM_REGISTRY_TOKENS"),
+				cli.File("/vela/parameters/npm/registry_tokens"),
+				cli.File("/vela/secrets/npm/registry_tokens"),
+			),
+		},
+		&cli.StringFlag{
+			Name:        "npm-version",
+			Usage:       "npm version or semver range to install before running, overrides the packageManager field of package.json",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_NPM_VERSION"),
+				cli.EnvVar("PLUGIN_NPM_VERSION"),
+				cli.File("/vela/parameters/npm/npm_version"),
+			),
+		},
+		&cli.StringFlag{
+			Name:        "email",
+			Aliases:     []string{"e"},
+			Usage:       "email for user",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_EMAIL"),
+				cli.EnvVar("PLUGIN_EMAIL"),
+				cli.EnvVar("NPM_EMAIL"),
+				cli.File("/vela/parameters/npm/email"),
+				cli.File("/vela/secrets/npm/email"),
+			),
+		},
+		&cli.BoolFlag{
+			Name:        "strict-ssl",
+			Usage:       "enables strict SSL",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_STRICT_SSL"),
+				cli.EnvVar("PLUGIN_STRICT_SSL"),
+				cli.EnvVar("STRICT_SSL"),
+				cli.File("/vela/parameters/npm/strict_ssl"),
+				cli.File("/vela/secrets/npm/strict_ssl"),
+			),
+		},
+		&cli.BoolFlag{
+			Name:        "always-auth",
+			Usage:       "enables always auth",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_ALWAYS_AUTH"),
+				cli.EnvVar("PLUGIN_ALWAYS_AUTH"),
+				cli.EnvVar("ALWAYS_AUTH"),
+				cli.File("/vela/parameters/npm/always_auth"),
+				cli.File("/vela/secrets/npm/always_auth"),
+			),
+		},
+		&cli.BoolFlag{
+			Name:        "skip-ping",
+			Usage:       "skips auth ping",
+			Value:       false,
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_SKIP_PING"),
+				cli.EnvVar("PLUGIN_SKIP_PING"),
+				cli.EnvVar("SKIP_PING"),
+				cli.File("/vela/parameters/npm/skip_ping"),
+				cli.File("/vela/secrets/npm/skip_ping"),
+			),
+		},
+		&cli.BoolFlag{
+			Name:        "first-publish",
+			Usage:       "(DEPRECATED): skips version lookup and verification for first time publishes",
+			DefaultText: "N/A",
+		},
+		&cli.StringFlag{
+			Name:        "log-level",
+			Usage:       "set log level - options: (trace|debug|info|warn|error|fatal|panic)",
+			Value:       "info",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_LOG"),
+				cli.EnvVar("PARAMETER_LOG_LEVEL"),
+				cli.EnvVar("PLUGIN_LOG"),
+				cli.EnvVar("PLUGIN_LOG_LEVEL"),
+				cli.EnvVar("LOG_LEVEL"),
+				cli.EnvVar("LOG"),
+				cli.File("/vela/parameters/npm/log_level"),
+				cli.File("/vela/secrets/npm/log_level"),
+			),
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "only pretend to perform the action",
+			Value:       false,
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PARAMETER_DRY_RUN"),
+				cli.EnvVar("PLUGIN_DRY_RUN"),
+				cli.EnvVar("DRY_RUN"),
+				cli.File("/vela/parameters/npm/dry_run"),
+				cli.File("/vela/secrets/npm/dry_run"),
+			),
+		},
+		&cli.StringFlag{
+			Name:        "ci",
+			Usage:       "set to CI environment",
+			DefaultText: "N/A",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CI"),
+				cli.File("/vela/parameters/npm/ci"),
+				cli.File("/vela/secrets/npm/ci"),
+			),
+		},
+	}
+}
+
+// actionFlag selects the subcommand so pipelines can switch behavior without changing the entrypoint.
+func actionFlag() *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:        "action",
+		Usage:       "subcommand to run when none is given - options: (publish|verify|pack|dist-tag|deprecate|unpublish|access|info)",
+		Value:       npm.PublishAction,
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_ACTION"),
+			cli.EnvVar("PLUGIN_ACTION"),
+			cli.File("/vela/parameters/npm/action"),
+			cli.File("/vela/secrets/npm/action"),
+		),
+	}
+}
+
+// tagFlag publishes or updates the given dist-tag.
+func tagFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "tag",
+		Usage:       "dist-tag to publish the package with or to update",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_TAG"),
+			cli.EnvVar("PLUGIN_TAG"),
+			cli.EnvVar("TAG"),
+			cli.File("/vela/parameters/npm/tag"),
+			cli.File("/vela/secrets/npm/tag"),
+		),
+	}
+}
+
+// auditReportFlag writes the npm audit findings to SARIF or JSON files.
+func auditReportFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "audit-report",
+		Usage:       "files to write the npm audit findings to, .sarif files are written as SARIF 2.1.0 and .json files in the plugin's JSON schema",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_AUDIT_REPORT"),
+			cli.EnvVar("PLUGIN_AUDIT_REPORT"),
+			cli.File("/vela/parameters/npm/audit_report"),
+		),
+	}
+}
+
+// licenseAllowFlag lists the licenses dependencies may use.
+func licenseAllowFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "license-allow",
+		Usage:       "SPDX licenses or patterns, e.g. MIT or BSD-*, the production dependencies may use, any license not denied is allowed when empty",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_LICENSE_ALLOW"),
+			cli.EnvVar("PLUGIN_LICENSE_ALLOW"),
+			cli.File("/vela/parameters/npm/license_allow"),
+		),
+	}
+}
+
+// licenseDenyFlag lists the licenses dependencies may not use.
+func licenseDenyFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "license-deny",
+		Usage:       "SPDX licenses or patterns, e.g. GPL-* or AGPL-*, the production dependencies may not use, takes precedence over license-allow",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_LICENSE_DENY"),
+			cli.EnvVar("PLUGIN_LICENSE_DENY"),
+			cli.File("/vela/parameters/npm/license_deny"),
+		),
+	}
+}
+
+// licenseModeFlag sets whether license violations fail the release.
+func licenseModeFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "license-mode",
+		Usage: "whether license violations fail the release or only warn - options: (fail|warn)",
+		Value: npm.LicenseModeFail,
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_LICENSE_MODE"),
+			cli.EnvVar("PLUGIN_LICENSE_MODE"),
+			cli.File("/vela/parameters/npm/license_mode"),
+		),
+	}
+}
+
+// lockfileCheckFlag checks package-lock.json before publishing.
+func lockfileCheckFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "lockfile-check",
+		Usage:       "fail when package-lock.json is out of sync with package.json, misses integrity hashes or resolves packages from registries that are not allowed",
+		Value:       false,
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_LOCKFILE_CHECK"),
+			cli.EnvVar("PLUGIN_LOCKFILE_CHECK"),
+			cli.File("/vela/parameters/npm/lockfile_check"),
+		),
+	}
+}
+
+// allowedRegistriesFlag lists the registries package-lock.json may resolve packages from.
+func allowedRegistriesFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "allowed-registries",
+		Usage:       "registry URLs package-lock.json may resolve packages from, defaults to the registry, registry-tokens and publishConfig registries",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_ALLOWED_REGISTRIES"),
+			cli.EnvVar("PLUGIN_ALLOWED_REGISTRIES"),
+			cli.File("/vela/parameters/npm/allowed_registries"),
+		),
+	}
+}
+
+// auditLevelFlag sets the severity at which npm audit fails.
+func auditLevelFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "audit-level",
+		Usage:       "The level at which an npm audit will fail - options: (none|low|moderate|high|critical)",
+		Value:       "none",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_AUDIT_LEVEL"),
+			cli.EnvVar("PARAMETER_AUDIT"),
+			cli.EnvVar("PLUGIN_AUDIT_LEVEL"),
+			cli.EnvVar("PLUGIN_AUDIT"),
+			cli.EnvVar("AUDIT_LEVEL"),
+			cli.EnvVar("AUDIT"),
+			cli.File("/vela/parameters/npm/audit_level"),
+			cli.File("/vela/secrets/npm/audit_level"),
+		),
+	}
+}
+
+// accessFlag sets public or restricted package visibility.
+func accessFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "access",
+		Usage:       "Tells the registry whether this package should be published as public or restricted. Only applies to scoped packages, which default to restricted",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_ACCESS"),
+			cli.EnvVar("PARAMETER_ACCESS"),
+			cli.EnvVar("ACCESS"),
+			cli.File("/vela/parameters/npm/access"),
+			cli.File("/vela/secrets/npm/access"),
+		),
+	}
+}
+
+// workspacesFlag selects every workspace.
+func workspacesFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "workspaces",
+		Usage:       "publish all workspaces",
+		Value:       false,
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_WORKSPACES"),
+			cli.EnvVar("PLUGIN_WORKSPACES"),
+			cli.EnvVar("WORKSPACES"),
+			cli.EnvVar("WS"),
+			cli.File("/vela/parameters/npm/workspaces"),
+			cli.File("/vela/secrets/npm/workspaces"),
+		),
+	}
+}
+
+// workspaceFlag selects a single workspace.
+func workspaceFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "workspace",
+		Usage:       "publish a specific workspace",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_WORKSPACE"),
+			cli.EnvVar("PLUGIN_WORKSPACE"),
+			cli.EnvVar("WORKSPACE"),
+			cli.EnvVar("W"),
+			cli.File("/vela/parameters/npm/workspace"),
+			cli.File("/vela/secrets/npm/workspace"),
+		),
+	}
+}
+
+// packageFlag names the package to act on.
+func packageFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "package",
+		Usage:       "name (or name@version to unpublish) of the package to act on, defaults to the package.json name",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_PACKAGE"),
+			cli.EnvVar("PLUGIN_PACKAGE"),
+			cli.File("/vela/parameters/npm/package"),
+			cli.File("/vela/secrets/npm/package"),
+		),
+	}
+}
+
+// versionRangeFlag selects the published versions to deprecate.
+func versionRangeFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "version-range",
+		Usage:       "semver range of published versions to deprecate",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_VERSION_RANGE"),
+			cli.EnvVar("PLUGIN_VERSION_RANGE"),
+			cli.File("/vela/parameters/npm/version_range"),
+			cli.File("/vela/secrets/npm/version_range"),
+		),
+	}
+}
+
+// messageFlag is the deprecation message.
+func messageFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "message",
+		Usage:       "deprecation message, an empty message will un-deprecate",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_MESSAGE"),
+			cli.EnvVar("PLUGIN_MESSAGE"),
+			cli.File("/vela/parameters/npm/message"),
+			cli.File("/vela/secrets/npm/message"),
+		),
+	}
+}
+
+// confirmFlag guards unpublishing a range or an entire package.
+func confirmFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "confirm",
+		Usage:       "package name confirming an unpublish of a range or an entire package",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_CONFIRM"),
+			cli.EnvVar("PLUGIN_CONFIRM"),
+			cli.File("/vela/parameters/npm/confirm"),
+			cli.File("/vela/secrets/npm/confirm"),
+		),
+	}
+}
+
+// packDestinationFlag is where tarballs are written.
+func packDestinationFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "pack-destination",
+		Usage:       "directory the pack action writes tarballs and the pack manifest to",
+		Value:       ".",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_PACK_DESTINATION"),
+			cli.EnvVar("PLUGIN_PACK_DESTINATION"),
+			cli.File("/vela/parameters/npm/pack_destination"),
+			cli.File("/vela/secrets/npm/pack_destination"),
+		),
+	}
+}
+
+// tarballFlag selects prebuilt tarballs to publish.
+func tarballFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "tarball",
+		Usage:       "path or glob of prebuilt tarballs to publish",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_TARBALL"),
+			cli.EnvVar("PLUGIN_TARBALL"),
+			cli.File("/vela/parameters/npm/tarball"),
+			cli.File("/vela/secrets/npm/tarball"),
+		),
+	}
+}
+
+// workspaceIncludeFlag selects workspaces by package name, directory or glob.
+func workspaceIncludeFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "workspace-include",
+		Usage:       "only select workspaces matching these package names, directories or globs",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_WORKSPACE_INCLUDE"),
+			cli.EnvVar("PLUGIN_WORKSPACE_INCLUDE"),
+			cli.File("/vela/parameters/npm/workspace_include"),
+			cli.File("/vela/secrets/npm/workspace_include"),
+		),
+	}
+}
+
+// workspaceExcludeFlag drops workspaces by package name, directory or glob.
+func workspaceExcludeFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "workspace-exclude",
+		Usage:       "skip workspaces matching these package names, directories or globs",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_WORKSPACE_EXCLUDE"),
+			cli.EnvVar("PLUGIN_WORKSPACE_EXCLUDE"),
+			cli.File("/vela/parameters/npm/workspace_exclude"),
+			cli.File("/vela/secrets/npm/workspace_exclude"),
+		),
+	}
+}
+
+// grantsFlag declares team permissions for the access subcommand.
+func grantsFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "grants",
+		Usage:       "team grants for the access action in the form scope:team=(read-only|read-write|none)",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_GRANTS"),
+			cli.EnvVar("PLUGIN_GRANTS"),
+			cli.File("/vela/parameters/npm/grants"),
+			cli.File("/vela/secrets/npm/grants"),
+		),
+	}
+}
+
+// removeFlag removes the dist-tag instead of adding it.
+func removeFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "remove",
+		Usage:       "remove the dist-tag instead of adding it",
+		Value:       false,
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_REMOVE"),
+			cli.EnvVar("PLUGIN_REMOVE"),
+			cli.File("/vela/parameters/npm/remove"),
+			cli.File("/vela/secrets/npm/remove"),
+		),
+	}
+}
+
+// skipExistingFlag publishes only packages whose version is not in the registry.
+func skipExistingFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "skip-existing",
+		Usage:       "skip packages whose version is already published instead of failing",
+		Value:       false,
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_SKIP_EXISTING"),
+			cli.EnvVar("PLUGIN_SKIP_EXISTING"),
+			cli.File("/vela/parameters/npm/skip_existing"),
+			cli.File("/vela/secrets/npm/skip_existing"),
+		),
+	}
+}
+
+// changedSinceFlag limits the release to workspaces changed since a git ref.
+func changedSinceFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "changed-since",
+		Usage:       "only release workspaces with files changed since this git ref, and their dependents",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_CHANGED_SINCE"),
+			cli.EnvVar("PLUGIN_CHANGED_SINCE"),
+			cli.File("/vela/parameters/npm/changed_since"),
+			cli.File("/vela/secrets/npm/changed_since"),
+		),
+	}
+}
+
+// changedTagPatternFlag compares against the latest tag matching a pattern when no ref is given.
+func changedTagPatternFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "changed-tag-pattern",
+		Usage:       "compare against the latest git tag matching this pattern when changed_since is not set",
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_CHANGED_TAG_PATTERN"),
+			cli.EnvVar("PLUGIN_CHANGED_TAG_PATTERN"),
+			cli.File("/vela/parameters/npm/changed_tag_pattern"),
+			cli.File("/vela/secrets/npm/changed_tag_pattern"),
+		),
+	}
+}
+
+// parallelismFlag limits how many packages are checked against the registry at once.
+func parallelismFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:        "parallelism",
+		Usage:       "number of packages to check against the registry at the same time",
+		Value:       4,
+		DefaultText: "N/A",
+		Sources: cli.NewValueSourceChain(
+			cli.EnvVar("PARAMETER_PARALLELISM"),
+			cli.EnvVar("PLUGIN_PARALLELISM"),
+			cli.File("/vela/parameters/npm/parallelism"),
+			cli.File("/vela/secrets/npm/parallelism"),
+		),
+	}
+}
@@ -9,6 +9,7 @@ require (
 	github.com/sirupsen/logrus v1.9.4
 	github.com/spf13/afero v1.15.0
 	github.com/urfave/cli/v3 v3.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ReadOnly grants a team read access to a package.
+	ReadOnly = "read-only"
+	// ReadWrite grants a team read and write access to a package.
+	ReadWrite = "read-write"
+	// Revoke removes a team's access to a package.
+	Revoke = "none"
+)
+
+// teamGrant is the desired permission of a team on a package.
+type teamGrant struct {
+	Team       string
+	Permission string
+}
+
+// accessChange is a single command needed to reach the desired access state.
+type accessChange struct {
+	Package string
+	Change  string
+	Args    []string
+}
+
+// accessPlugin manages package visibility and team grants.
+type accessPlugin struct {
+	*plugin
+}
+
+// Exec runs the access plugin.
+func (p *accessPlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	return p.manageAccess()
+}
+
+// parseGrant parses a grant in the form scope:team=permission.
+func parseGrant(grant string) (teamGrant, error) {
+	team, perm, ok := strings.Cut(grant, "=")
+	if !ok || !strings.Contains(team, ":") {
+		return teamGrant{}, fmt.Errorf("grant %s is not in the form scope:team=permission", grant)
+	}
+
+	g := teamGrant{Team: strings.TrimSpace(team)}
+
+	switch strings.ToLower(strings.TrimSpace(perm)) {
+	case "read", "read-only":
+		g.Permission = ReadOnly
+	case "write", "read-write":
+		g.Permission = ReadWrite
+	case "none", "revoke":
+		g.Permission = Revoke
+	default:
+		return teamGrant{}, fmt.Errorf("grant permission %s is not recognized, use 'read-only', 'read-write' or 'none'", perm)
+	}
+
+	return g, nil
+}
+
+// manageAccess diffs the desired visibility and team grants against the
+// registry and applies only what changed.
+// https://docs.npmjs.com/cli/commands/npm-access
+func (p *plugin) manageAccess() error {
+	names, err := p.targetPackages()
+	if err != nil {
+		return err
+	}
+
+	grants := make([]teamGrant, 0, len(p.config.Grants))
+
+	for _, g := range p.config.Grants {
+		grant, err := parseGrant(g)
+		if err != nil {
+			return err
+		}
+
+		grants = append(grants, grant)
+	}
+
+	// packages each team currently has access to, looked up once per team
+	teams := make(map[string]map[string]string)
+
+	for _, g := range grants {
+		if _, ok := teams[g.Team]; ok {
+			continue
+		}
+
+		current, err := p.accessJSON("list", "packages", g.Team)
+		if err != nil {
+			return fmt.Errorf("failed to list packages for team %s: %w", g.Team, err)
+		}
+
+		teams[g.Team] = current
+	}
+
+	var changes []accessChange
+
+	for _, name := range names {
+		if len(p.config.Access) > 0 {
+			status, err := p.accessJSON("get", "status", name)
+			if err != nil {
+				return fmt.Errorf("failed to get access status for %s: %w", name, err)
+			}
+
+			if status[name] != p.config.Access {
+				changes = append(changes, accessChange{
+					Package: name,
+					Change:  fmt.Sprintf("set visibility %s -> %s", status[name], p.config.Access),
+					Args:    []string{"access", "set", "status=" + p.config.Access, name},
+				})
+			}
+		}
+
+		for _, g := range grants {
+			current, ok := teams[g.Team][name]
+
+			switch {
+			case g.Permission == Revoke && ok:
+				changes = append(changes, accessChange{
+					Package: name,
+					Change:  fmt.Sprintf("revoke %s from %s", current, g.Team),
+					Args:    []string{"access", "revoke", g.Team, name},
+				})
+			case g.Permission != Revoke && current != g.Permission:
+				changes = append(changes, accessChange{
+					Package: name,
+					Change:  fmt.Sprintf("grant %s to %s", g.Permission, g.Team),
+					Args:    []string{"access", "grant", g.Permission, g.Team, name},
+				})
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		log.Info("Package access is already up to date")
+
+		return nil
+	}
+
+	for _, c := range changes {
+		log.WithFields(log.Fields{
+			"package": c.Package,
+			"change":  c.Change,
+		}).Info("Planned access change")
+	}
+
+	if p.config.DryRun {
+		log.Info("Doing a dry run, access changes will not be applied")
+
+		return nil
+	}
+
+	for _, c := range changes {
+		args := append(c.Args, "--registry", p.config.Registry)
+
+		if _, err := p.cli.RunCommandBytes("npm", args...); err != nil {
+			return fmt.Errorf("failed to %s on %s: %w", c.Change, c.Package, err)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"changes": len(changes),
+	}).Info("Successfully applied access changes")
+
+	return nil
+}
+
+// accessJSON runs an npm access query and parses the package to value response.
+func (p *plugin) accessJSON(args ...string) (map[string]string, error) {
+	args = append([]string{"access"}, args...)
+	args = append(args, "--json", "--registry", p.config.Registry)
+
+	out, err := p.cli.RunCommandBytes("npm", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string)
+	if err := json.Unmarshal(out, &res); err != nil {
+		return nil, fmt.Errorf("failed to convert npm access response: %w", err)
+	}
+
+	log.Trace(res)
+
+	return res, nil
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+func TestPlugin_manageAccess(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "@corp/ui",
+		Access:   "restricted",
+		Grants:   []string{"corp:readers=read", "corp:writers=read-write", "corp:old=none"},
+		Registry: "http://registry.test.com",
+	})
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "list", "packages", "corp:readers", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "list", "packages", "corp:writers", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"@corp/ui": "read-write"}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "list", "packages", "corp:old", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"@corp/ui": "read-only"}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "get", "status", "@corp/ui", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"@corp/ui": "restricted"}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "grant", "read-only", "corp:readers", "@corp/ui", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "revoke", "corp:old", "@corp/ui", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err := p.manageAccess()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_manageAccess_DryRun(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "@corp/ui",
+		Access:   "public",
+		DryRun:   true,
+		Registry: "http://registry.test.com",
+	})
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "get", "status", "@corp/ui", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"@corp/ui": "restricted"}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"access", "set", "status=public", "@corp/ui", "--registry", "http://registry.test.com"})).
+		Times(0)
+
+	err := p.manageAccess()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseGrant(t *testing.T) {
+	g, err := parseGrant("corp:devs=write")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if g.Team != "corp:devs" || g.Permission != ReadWrite {
+		t.Errorf("unexpected grant %v", g)
+	}
+
+	if _, err := parseGrant("devs=read"); err == nil {
+		t.Error("grant without a scope should fail")
+	}
+
+	if _, err := parseGrant("corp:devs=admin"); err == nil {
+		t.Error("grant with an unknown permission should fail")
+	}
+}
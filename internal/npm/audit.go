@@ -0,0 +1,413 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-vela/vela-npm/internal/shell"
+)
+
+// severities are the npm audit severities from lowest to highest.
+var severities = []string{"info", Low, Moderate, High, Critical}
+
+// severityRank orders a severity, unknown severities rank lowest.
+func severityRank(severity string) int {
+	return slices.Index(severities, strings.ToLower(severity))
+}
+
+// auditFinding is a single advisory affecting a package.
+type auditFinding struct {
+	Package  string
+	Severity string
+	// ID is the GHSA ID of the advisory, or the npm advisory number when it has none.
+	ID           string
+	Title        string
+	URL          string
+	Range        string
+	FixAvailable bool
+	// Paths are the dependency paths leading to the package, e.g. app > mkdirp > minimist.
+	Paths []string
+}
+
+// auditVia is an advisory causing a vulnerability, npm also lists the names
+// of vulnerable dependencies here which are reported on their own.
+type auditVia struct {
+	Source   int    `json:"source"`
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Severity string `json:"severity"`
+	Range    string `json:"range"`
+}
+
+// auditReport is the npm audit --json output, vulnerabilities are reported by
+// npm 7 and newer and advisories by npm 6.
+// https://docs.npmjs.com/cli/commands/npm-audit#json
+type auditReport struct {
+	Vulnerabilities map[string]struct {
+		Via          []json.RawMessage `json:"via"`
+		Nodes        []string          `json:"nodes"`
+		FixAvailable json.RawMessage   `json:"fixAvailable"`
+	} `json:"vulnerabilities"`
+	Advisories map[string]struct {
+		ID                 int    `json:"id"`
+		ModuleName         string `json:"module_name"`
+		Severity           string `json:"severity"`
+		Title              string `json:"title"`
+		URL                string `json:"url"`
+		GithubAdvisoryID   string `json:"github_advisory_id"`
+		VulnerableVersions string `json:"vulnerable_versions"`
+		PatchedVersions    string `json:"patched_versions"`
+		Findings           []struct {
+			Paths []string `json:"paths"`
+		} `json:"findings"`
+	} `json:"advisories"`
+}
+
+// audit runs npm audit for the whole project, or only the given workspaces.
+func (p *plugin) audit(workspaces []string) error {
+	if len(p.config.AuditReport) > 0 && !p.npmPublishes() {
+		log.Warn("audit_report is only written for npm audits")
+	}
+
+	if ok, _ := p.os.Exists(auditExceptionsFile); ok && !p.npmPublishes() {
+		log.Warnf("%s is only applied to npm audits, its exceptions are ignored for %s", auditExceptionsFile, p.manager)
+	}
+
+	if p.config.AuditLevel == None {
+		// the audit still runs for the report, without failing
+		if len(p.config.AuditReport) == 0 || !p.npmPublishes() {
+			log.Warn("Audit level set to NONE, skipping audit check")
+
+			return nil
+		}
+
+		log.Warn("Audit level set to NONE, the audit only writes the audit report")
+	}
+
+	switch p.manager {
+	case yarnManager:
+		return p.yarnAudit(workspaces)
+	case pnpmManager:
+		return p.pnpmAudit()
+	}
+
+	// Running audit will error if given audit-level or higher is found
+	// https://docs.npmjs.com/cli/v6/commands/npm-audit
+	log.Info("Running audit check")
+
+	exceptions, err := p.readAuditExceptions()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"audit", "--json", p.productionFlag(), "--audit-level=" + p.config.AuditLevel}
+
+	// only audit the workspaces being released, leaving out private ones
+	for _, w := range workspaces {
+		args = append(args, "--workspace", w)
+	}
+
+	out, cmdErr := p.cli.RunCommandBytes("npm", args...)
+	if cmdErr != nil {
+		log.Trace(fmt.Errorf("audit command failed: %w", cmdErr))
+
+		var errResp shell.NPMErrorResponse
+		if err := json.Unmarshal(out, &errResp); (err == nil && errResp != shell.NPMErrorResponse{}) {
+			if errResp.ErrorBlock.Code == "ENOLOCK" { // ENOLOCK -> requires lockfile
+				return errors.New(errResp.ErrorBlock.Summary + " " + errResp.ErrorBlock.Detail)
+			} else if errResp.ErrorBlock.Code == "ENOAUDIT" { // ENOAUDIT -> valid registry but it doesn't support audits
+				log.Warn(errResp.ErrorBlock.Summary + " Try adding a .npmrc to your project directory or set `audit-level: none`.")
+			} else { // Unknown error response code
+				return errors.New(errResp.ErrorBlock.Summary + " " + errResp.ErrorBlock.Detail)
+			}
+		}
+	}
+
+	var report auditReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		log.Debugf("failed to convert npm audit response: %v", err)
+	}
+
+	findings := report.findings(p.auditPaths(workspaces))
+
+	if len(findings) > 0 {
+		logAuditFindings(findings, p.config.AuditLevel)
+	} else if cmdErr == nil {
+		log.Info("Audit found no vulnerabilities")
+	}
+
+	remaining := applyAuditExceptions(findings, exceptions, time.Now())
+	crossed := findingsAtLevel(remaining, p.config.AuditLevel)
+
+	// without advisories there is nothing to report, so rely on the exit code of npm
+	failed := len(crossed) > 0 || (len(findings) == 0 && cmdErr != nil)
+
+	if err := p.writeAuditReports(auditResults(findings, remaining, crossed, exceptions), !failed); err != nil {
+		return err
+	}
+
+	switch {
+	case len(crossed) > 0:
+		return auditError(crossed, p.config.AuditLevel, auditHint(args))
+	case failed:
+		return fmt.Errorf("audit failed for audit-level=%s, %s", p.config.AuditLevel, auditHint(args))
+	default:
+		return nil
+	}
+}
+
+// auditHint tells the user how to reproduce the audit with the arguments it
+// ran with, leaving out the JSON output.
+func auditHint(args []string) string {
+	args = slices.DeleteFunc(slices.Clone(args), func(a string) bool { return a == "--json" })
+
+	return fmt.Sprintf("run `npm %s` to view vulnerabilities that need fixed", strings.Join(args, " "))
+}
+
+// findings converts the report to one finding per advisory and package,
+// ordered from the highest severity. The dependency path of every installed
+// location npm reports is resolved with pathOf.
+func (r auditReport) findings(pathOf func(node string) string) []auditFinding {
+	byKey := make(map[string]*auditFinding)
+
+	add := func(f auditFinding) {
+		key := f.ID + " " + f.Package
+		if existing, ok := byKey[key]; ok {
+			existing.Paths = appendUnique(existing.Paths, f.Paths...)
+			existing.FixAvailable = existing.FixAvailable || f.FixAvailable
+
+			return
+		}
+
+		byKey[key] = &f
+	}
+
+	for name, v := range r.Vulnerabilities {
+		paths := make([]string, 0, len(v.Nodes))
+		for _, n := range v.Nodes {
+			paths = append(paths, pathOf(n))
+		}
+
+		for _, raw := range v.Via {
+			var via auditVia
+			// vulnerable dependencies are listed by name and have their own entry
+			if err := json.Unmarshal(raw, &via); err != nil {
+				continue
+			}
+
+			pkg := via.Name
+			if len(pkg) == 0 {
+				pkg = name
+			}
+
+			add(auditFinding{
+				Package:      pkg,
+				Severity:     via.Severity,
+				ID:           advisoryID(via.URL, via.Source),
+				Title:        via.Title,
+				URL:          via.URL,
+				Range:        via.Range,
+				FixAvailable: fixAvailable(v.FixAvailable),
+				Paths:        paths,
+			})
+		}
+	}
+
+	for _, a := range r.Advisories {
+		var paths []string
+		for _, f := range a.Findings {
+			for _, chain := range f.Paths {
+				paths = append(paths, strings.ReplaceAll(chain, ">", " > "))
+			}
+		}
+
+		id := a.GithubAdvisoryID
+		if len(id) == 0 {
+			id = advisoryID(a.URL, a.ID)
+		}
+
+		add(auditFinding{
+			Package:      a.ModuleName,
+			Severity:     a.Severity,
+			ID:           id,
+			Title:        a.Title,
+			URL:          a.URL,
+			Range:        a.VulnerableVersions,
+			FixAvailable: a.PatchedVersions != "<0.0.0" && len(a.PatchedVersions) > 0,
+			Paths:        paths,
+		})
+	}
+
+	findings := make([]auditFinding, 0, len(byKey))
+	for _, f := range byKey {
+		sort.Strings(f.Paths)
+		findings = append(findings, *f)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if ra, rb := severityRank(a.Severity), severityRank(b.Severity); ra != rb {
+			return ra > rb
+		}
+
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+
+		return a.ID < b.ID
+	})
+
+	return findings
+}
+
+// advisoryID returns the GHSA ID from the advisory URL, falling back to the npm advisory number.
+func advisoryID(url string, source int) string {
+	if i := strings.LastIndex(url, "/"); i >= 0 && strings.HasPrefix(url[i+1:], "GHSA-") {
+		return url[i+1:]
+	}
+
+	return strconv.Itoa(source)
+}
+
+// fixAvailable reads the fixAvailable field, which is a boolean or the fixing package.
+func fixAvailable(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+
+	return len(s) > 0 && s != "false" && s != "null"
+}
+
+// auditPaths resolves the installed locations npm audit reports to the chain of
+// dependents leading to them from package-lock.json, e.g. app > mkdirp > minimist
+// for a hoisted node_modules/minimist, starting at the audited workspaces or at
+// the root package and every workspace.
+func (p *plugin) auditPaths(workspaces []string) func(node string) string {
+	lock, err := p.readPackageLock()
+	if err != nil || lock == nil {
+		if err != nil {
+			log.Debugf("dependency paths fall back to install locations: %v", err)
+		}
+
+		return nodePath
+	}
+
+	var starts []string
+
+	for _, w := range workspaces {
+		starts = append(starts, path.Clean(w))
+	}
+
+	if len(starts) == 0 {
+		for _, loc := range sortedKeys(lock.Packages) {
+			if !inNodeModules(loc) {
+				starts = append(starts, loc)
+			}
+		}
+	}
+
+	paths := dependencyPaths(lock.Packages, starts)
+
+	return func(node string) string {
+		if chain, ok := paths[node]; ok {
+			return strings.Join(chain, " > ")
+		}
+
+		return nodePath(node)
+	}
+}
+
+// nodePath converts an installed location, e.g. node_modules/a/node_modules/b,
+// to a dependency path, used when the lockfile does not lead to the location.
+func nodePath(node string) string {
+	var parts []string
+
+	for _, part := range strings.Split(node, "node_modules/") {
+		if part = strings.Trim(part, "/"); len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, " > ")
+}
+
+// appendUnique appends the values not already in the slice.
+func appendUnique(s []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(s, v) {
+			s = append(s, v)
+		}
+	}
+
+	return s
+}
+
+// findingsAtLevel returns the findings at or above the audit level.
+func findingsAtLevel(findings []auditFinding, level string) []auditFinding {
+	var crossed []auditFinding
+
+	if level == None {
+		return crossed
+	}
+
+	for _, f := range findings {
+		if severityRank(f.Severity) >= severityRank(level) {
+			crossed = append(crossed, f)
+		}
+	}
+
+	return crossed
+}
+
+// logAuditFindings logs the number of findings per severity and every finding,
+// grouped by severity, warning about those at or above the audit level.
+func logAuditFindings(findings []auditFinding, level string) {
+	counts := make(map[string]int, len(severities))
+	for _, f := range findings {
+		counts[strings.ToLower(f.Severity)]++
+	}
+
+	summary := log.Fields{"total": len(findings)}
+	for _, s := range severities {
+		summary[s] = counts[s]
+	}
+
+	log.WithFields(summary).Info("Audit summary")
+
+	for _, f := range findings {
+		entry := log.WithFields(log.Fields{
+			"package":  f.Package,
+			"severity": f.Severity,
+			"range":    f.Range,
+			"fix":      f.FixAvailable,
+			"paths":    strings.Join(f.Paths, ", "),
+		})
+
+		// with audit-level none nothing is enforced, so findings are only informational
+		if level != None && severityRank(f.Severity) >= severityRank(level) {
+			entry.Warnf("%s %s", f.ID, f.Title)
+		} else {
+			entry.Infof("%s %s", f.ID, f.Title)
+		}
+	}
+}
+
+// auditError names every advisory that crossed the audit level.
+func auditError(crossed []auditFinding, level, hint string) error {
+	advisories := make([]string, 0, len(crossed))
+	for _, f := range crossed {
+		advisories = append(advisories, fmt.Sprintf("%s (%s %s)", f.ID, f.Package, f.Severity))
+	}
+
+	return fmt.Errorf("audit found %d advisories at or above audit-level=%s: %s, %s",
+		len(crossed), level, strings.Join(advisories, ", "), hint)
+}
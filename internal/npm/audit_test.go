@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+)
+
+// testAuditReport is an npm 7+ audit report with a direct and a transitive vulnerability.
+const testAuditReport = `{
+	"auditReportVersion": 2,
+	"vulnerabilities": {
+		"minimist": {
+			"name": "minimist",
+			"severity": "critical",
+			"via": [
+				{
+					"source": 1097678,
+					"name": "minimist",
+					"title": "Prototype Pollution in minimist",
+					"url": "https://github.com/advisories/GHSA-xvch-5gv4-984h",
+					"severity": "critical",
+					"range": "<0.2.4"
+				}
+			],
+			"nodes": ["node_modules/mkdirp/node_modules/minimist", "packages/a/node_modules/minimist"],
+			"fixAvailable": {"name": "mkdirp", "version": "1.0.4", "isSemVerMajor": true}
+		},
+		"mkdirp": {
+			"name": "mkdirp",
+			"severity": "critical",
+			"via": ["minimist"],
+			"nodes": ["node_modules/mkdirp"],
+			"fixAvailable": true
+		},
+		"semver": {
+			"name": "semver",
+			"severity": "moderate",
+			"via": [
+				{
+					"source": 1096482,
+					"name": "semver",
+					"title": "semver vulnerable to Regular Expression Denial of Service",
+					"url": "https://github.com/advisories/GHSA-c2qf-rxjj-qqgw",
+					"severity": "moderate",
+					"range": ">=7.0.0 <7.5.2"
+				}
+			],
+			"nodes": ["node_modules/semver"],
+			"fixAvailable": false
+		}
+	},
+	"metadata": {"vulnerabilities": {"info": 0, "low": 0, "moderate": 1, "high": 0, "critical": 2, "total": 3}}
+}`
+
+func TestAuditReport_findings(t *testing.T) {
+	var report auditReport
+	if err := json.Unmarshal([]byte(testAuditReport), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []auditFinding{
+		{
+			Package:      "minimist",
+			Severity:     "critical",
+			ID:           "GHSA-xvch-5gv4-984h",
+			Title:        "Prototype Pollution in minimist",
+			URL:          "https://github.com/advisories/GHSA-xvch-5gv4-984h",
+			Range:        "<0.2.4",
+			FixAvailable: true,
+			Paths:        []string{"mkdirp > minimist", "packages/a > minimist"},
+		},
+		{
+			Package:  "semver",
+			Severity: "moderate",
+			ID:       "GHSA-c2qf-rxjj-qqgw",
+			Title:    "semver vulnerable to Regular Expression Denial of Service",
+			URL:      "https://github.com/advisories/GHSA-c2qf-rxjj-qqgw",
+			Range:    ">=7.0.0 <7.5.2",
+			Paths:    []string{"semver"},
+		},
+	}
+
+	if got := report.findings(nodePath); !reflect.DeepEqual(got, want) {
+		t.Errorf("findings() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAuditReport_findings_Npm6(t *testing.T) {
+	res := `{
+		"advisories": {
+			"1179": {
+				"id": 1179,
+				"module_name": "minimist",
+				"severity": "low",
+				"title": "Prototype Pollution",
+				"url": "https://npmjs.com/advisories/1179",
+				"github_advisory_id": "GHSA-vh95-rmgr-6w4m",
+				"vulnerable_versions": "<0.2.1",
+				"patched_versions": ">=0.2.1",
+				"findings": [{"paths": ["mkdirp>minimist"]}]
+			}
+		}
+	}`
+
+	var report auditReport
+	if err := json.Unmarshal([]byte(res), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	got := report.findings(nodePath)
+	if len(got) != 1 || got[0].ID != "GHSA-vh95-rmgr-6w4m" || !got[0].FixAvailable || got[0].Paths[0] != "mkdirp > minimist" {
+		t.Errorf("findings() = %+v", got)
+	}
+}
+
+func TestPlugin_audit_NamesAdvisories(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{AuditLevel: High})
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=high"})).
+		Return([]byte(testAuditReport), errors.New("exit status 1"))
+
+	err := p.audit(nil)
+	if err == nil {
+		t.Fatal("audit should fail on a critical advisory")
+	}
+
+	if !strings.Contains(err.Error(), "GHSA-xvch-5gv4-984h (minimist critical)") {
+		t.Errorf("error does not name the advisory: %v", err)
+	}
+
+	if strings.Contains(err.Error(), "GHSA-c2qf-rxjj-qqgw") {
+		t.Errorf("error names an advisory below the audit level: %v", err)
+	}
+}
+
+func TestPlugin_audit_WorkspaceHint(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{AuditLevel: High})
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=high", "--workspace", "packages/a"})).
+		Return([]byte(testAuditReport), errors.New("exit status 1"))
+
+	err := p.audit([]string{"packages/a"})
+	if err == nil || !strings.Contains(err.Error(), "run `npm audit --omit=dev --audit-level=high --workspace packages/a`") {
+		t.Errorf("error does not reproduce the workspace audit: %v", err)
+	}
+}
+
+func TestPlugin_audit_BelowLevel(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{AuditLevel: Critical})
+
+	res := `{"vulnerabilities": {"semver": {"via": [{"source": 1, "name": "semver", "severity": "moderate"}], "nodes": ["node_modules/semver"]}}}`
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=critical"})).
+		Return([]byte(res), nil)
+
+	if err := p.audit(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_auditPaths(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+
+	lock := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "workspaces": ["packages/a"], "dependencies": {"mkdirp": "^0.5.1", "semver": "^7.0.0"}},
+    "packages/a": {"name": "a", "version": "1.0.0", "dependencies": {"minimist": "^0.2.0"}},
+    "node_modules/a": {"resolved": "packages/a", "link": true},
+    "node_modules/mkdirp": {"version": "0.5.1", "dependencies": {"minimist": "0.0.8"}},
+    "node_modules/mkdirp/node_modules/minimist": {"version": "0.0.8"},
+    "packages/a/node_modules/minimist": {"version": "0.2.0"},
+    "node_modules/semver": {"version": "7.3.0"}
+  }
+}`
+
+	if err := afero.WriteFile(fs, packageLockFile, []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var report auditReport
+	if err := json.Unmarshal([]byte(testAuditReport), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	got := report.findings(p.auditPaths(nil))
+
+	if want := []string{"a > minimist", "app > mkdirp > minimist"}; !reflect.DeepEqual(got[0].Paths, want) {
+		t.Errorf("minimist paths = %v, want %v", got[0].Paths, want)
+	}
+
+	if want := []string{"app > semver"}; !reflect.DeepEqual(got[1].Paths, want) {
+		t.Errorf("semver paths = %v, want %v", got[1].Paths, want)
+	}
+
+	// only the audited workspace leads to its own minimist
+	got = report.findings(p.auditPaths([]string{"packages/a"}))
+
+	if want := []string{"a > minimist", "mkdirp > minimist"}; !reflect.DeepEqual(got[0].Paths, want) {
+		t.Errorf("workspace minimist paths = %v, want %v", got[0].Paths, want)
+	}
+}
+
+func TestLogAuditFindings_LevelNone(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	findings := []auditFinding{{Package: "minimist", Severity: "critical", ID: "GHSA-vh95-rmgr-6w4m"}}
+
+	logAuditFindings(findings, None)
+
+	for _, e := range hook.AllEntries() {
+		if e.Level == log.WarnLevel {
+			t.Errorf("finding logged as a warning without an audit level: %s", e.Message)
+		}
+	}
+
+	hook.Reset()
+	logAuditFindings(findings, "high")
+
+	if e := hook.LastEntry(); e == nil || e.Level != log.WarnLevel {
+		t.Error("finding at the audit level should be logged as a warning")
+	}
+}
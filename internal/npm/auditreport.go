@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// auditReportSARIF writes the findings as a SARIF 2.1.0 log.
+	auditReportSARIF = "sarif"
+	// auditReportJSON writes the findings in the plugin's own JSON schema.
+	auditReportJSON = "json"
+
+	// auditReportSchemaVersion is bumped whenever the JSON report changes incompatibly.
+	auditReportSchemaVersion = 1
+)
+
+const (
+	// findingFailed is an advisory at or above the audit level.
+	findingFailed = "failed"
+	// findingIgnored is an advisory ignored by an audit exception.
+	findingIgnored = "ignored"
+	// findingReported is an advisory below the audit level.
+	findingReported = "reported"
+)
+
+// sarifLevels maps the npm audit severities to SARIF result levels.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/os/sarif-v2.1.0-os.html#_Toc34317648
+var sarifLevels = map[string]string{
+	"info":   "note",
+	Low:      "note",
+	Moderate: "warning",
+	High:     "error",
+	Critical: "error",
+}
+
+// sarifLevel returns the SARIF result level of the severity, unknown severities are warnings.
+func sarifLevel(severity string) string {
+	if level, ok := sarifLevels[severity]; ok {
+		return level
+	}
+
+	return "warning"
+}
+
+// securitySeverities are the scores code scanning tools rank security results by.
+// https://docs.github.com/en/code-security/code-scanning/integrating-with-code-scanning/sarif-support-for-code-scanning#reportingdescriptor-object
+var securitySeverities = map[string]string{
+	"info":   "0.0",
+	Low:      "2.0",
+	Moderate: "5.5",
+	High:     "8.0",
+	Critical: "9.5",
+}
+
+// auditResult is a finding with what the audit decided about it.
+type auditResult struct {
+	auditFinding
+
+	Status string
+	// Reason is why an audit exception ignores the advisory.
+	Reason string
+}
+
+// auditResults decides the status of every finding, the remaining findings
+// are those not ignored by an exception and the crossed ones fail the audit.
+func auditResults(findings, remaining, crossed []auditFinding, exceptions []auditException) []auditResult {
+	key := func(f auditFinding) string { return f.ID + " " + f.Package }
+
+	status := make(map[string]string, len(findings))
+	for _, f := range remaining {
+		status[key(f)] = findingReported
+	}
+
+	for _, f := range crossed {
+		status[key(f)] = findingFailed
+	}
+
+	results := make([]auditResult, 0, len(findings))
+
+	for _, f := range findings {
+		r := auditResult{auditFinding: f, Status: status[key(f)]}
+
+		// npm 6 advisories may have no findings, the reports list no paths rather than null
+		if r.Paths == nil {
+			r.Paths = []string{}
+		}
+
+		if len(r.Status) == 0 {
+			r.Status = findingIgnored
+
+			for _, e := range exceptions {
+				if strings.EqualFold(e.ID, f.ID) {
+					r.Reason = e.Reason
+				}
+			}
+		}
+
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// auditReportFormat returns the format of a report file from its extension.
+func auditReportFormat(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".sarif":
+		return auditReportSARIF
+	case ".json":
+		// GitHub and others also name SARIF logs .sarif.json
+		if strings.HasSuffix(strings.ToLower(file), ".sarif.json") {
+			return auditReportSARIF
+		}
+
+		return auditReportJSON
+	default:
+		return ""
+	}
+}
+
+// writeAuditReports writes the audit results to every audit_report file.
+func (p *plugin) writeAuditReports(results []auditResult, passed bool) error {
+	for _, file := range p.config.AuditReport {
+		var (
+			report any
+			format = auditReportFormat(file)
+		)
+
+		if format == auditReportSARIF {
+			report = sarifReport(results)
+		} else {
+			report = p.jsonAuditReport(results, passed)
+		}
+
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to convert audit report: %w", err)
+		}
+
+		if dir := filepath.Dir(file); dir != "." {
+			if err := p.os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+
+		if err := p.os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write audit report %s: %w", file, err)
+		}
+
+		log.WithFields(log.Fields{
+			"path":   file,
+			"format": format,
+		}).Info("Wrote audit report")
+	}
+
+	return nil
+}
+
+// jsonAuditFinding is a finding in the JSON report.
+type jsonAuditFinding struct {
+	ID              string   `json:"id"`
+	Package         string   `json:"package"`
+	Severity        string   `json:"severity"`
+	Title           string   `json:"title"`
+	URL             string   `json:"url,omitempty"`
+	VulnerableRange string   `json:"vulnerableRange"`
+	FixAvailable    bool     `json:"fixAvailable"`
+	Paths           []string `json:"paths"`
+	Status          string   `json:"status"`
+	ExceptionReason string   `json:"exceptionReason,omitempty"`
+}
+
+// jsonAuditReport is the plugin's own audit report schema.
+type jsonAuditReport struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	AuditLevel    string             `json:"auditLevel"`
+	Passed        bool               `json:"passed"`
+	Summary       map[string]int     `json:"summary"`
+	Findings      []jsonAuditFinding `json:"findings"`
+}
+
+// jsonAuditReport converts the results to the JSON report.
+func (p *plugin) jsonAuditReport(results []auditResult, passed bool) jsonAuditReport {
+	report := jsonAuditReport{
+		SchemaVersion: auditReportSchemaVersion,
+		AuditLevel:    p.config.AuditLevel,
+		Passed:        passed,
+		Summary:       map[string]int{"total": len(results)},
+		Findings:      make([]jsonAuditFinding, 0, len(results)),
+	}
+
+	for _, s := range severities {
+		report.Summary[s] = 0
+	}
+
+	for _, r := range results {
+		report.Summary[strings.ToLower(r.Severity)]++
+
+		report.Findings = append(report.Findings, jsonAuditFinding{
+			ID:              r.ID,
+			Package:         r.Package,
+			Severity:        r.Severity,
+			Title:           r.Title,
+			URL:             r.URL,
+			VulnerableRange: r.Range,
+			FixAvailable:    r.FixAvailable,
+			Paths:           r.Paths,
+			Status:          r.Status,
+			ExceptionReason: r.Reason,
+		})
+	}
+
+	return report
+}
+
+// sarifLog is the subset of a SARIF 2.1.0 log written for the audit.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	Results    []sarifResult  `json:"results"`
+	Properties map[string]any `json:"properties"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	HelpURI              string            `json:"helpUri,omitempty"`
+	DefaultConfiguration map[string]string `json:"defaultConfiguration"`
+	Properties           map[string]any    `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	Properties   map[string]any     `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifReport converts the results to a SARIF log with a rule per advisory,
+// the severity to level mapping is documented in the run properties.
+func sarifReport(results []auditResult) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "vela-npm audit",
+			InformationURI: "https://github.com/go-vela/vela-npm",
+			Rules:          []sarifRule{},
+		}},
+		Results: make([]sarifResult, 0, len(results)),
+		Properties: map[string]any{
+			"severityLevels":     sarifLevels,
+			"securitySeverities": securitySeverities,
+		},
+	}
+
+	rules := make(map[string]bool)
+
+	// the lockfile is where the vulnerable versions are pinned
+	var location sarifLocation
+	location.PhysicalLocation.ArtifactLocation.URI = "package-lock.json"
+
+	for _, r := range results {
+		severity := strings.ToLower(r.Severity)
+
+		if !rules[r.ID] {
+			rules[r.ID] = true
+
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:                   r.ID,
+				ShortDescription:     sarifMessage{Text: r.Title},
+				HelpURI:              r.URL,
+				DefaultConfiguration: map[string]string{"level": sarifLevel(severity)},
+				Properties: map[string]any{
+					"tags":              []string{"security", "npm"},
+					"security-severity": securitySeverities[severity],
+				},
+			})
+		}
+
+		result := sarifResult{
+			RuleID: r.ID,
+			Level:  sarifLevel(severity),
+			Message: sarifMessage{Text: fmt.Sprintf("%s %s is vulnerable to %s (%s), dependency paths: %s",
+				r.Package, r.Range, r.Title, severity, strings.Join(r.Paths, ", "))},
+			Locations: []sarifLocation{location},
+			Properties: map[string]any{
+				"package":      r.Package,
+				"severity":     severity,
+				"fixAvailable": r.FixAvailable,
+				"paths":        r.Paths,
+				"status":       r.Status,
+			},
+		}
+
+		if r.Status == findingIgnored {
+			result.Suppressions = []sarifSuppression{{Kind: "external", Justification: r.Reason}}
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
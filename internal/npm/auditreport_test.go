@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestAuditReportFormat(t *testing.T) {
+	tests := map[string]string{
+		"audit.sarif":              auditReportSARIF,
+		"reports/audit.sarif.json": auditReportSARIF,
+		"audit.json":               auditReportJSON,
+		"audit.xml":                "",
+	}
+	for file, want := range tests {
+		if got := auditReportFormat(file); got != want {
+			t.Errorf("auditReportFormat(%s) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestConfig_Validate_AuditReport(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{UserName: "testuser", AuditReport: []string{"audit.txt"}})
+
+	if err := p.Validate(); err == nil {
+		t.Error("Validate() should fail on an unknown audit report format")
+	}
+}
+
+func TestPlugin_audit_Report(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{AuditLevel: None, AuditReport: []string{"reports/audit.sarif", "reports/audit.json"}})
+
+	exceptions := `{"exceptions": [{"id": "GHSA-c2qf-rxjj-qqgw", "reason": "not reachable", "expires": "2099-01-01"}]}`
+	if err := afero.WriteFile(fs, auditExceptionsFile, []byte(exceptions), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=none"})).
+		Return([]byte(testAuditReport), nil)
+
+	// warn only, the report is written without failing
+	if err := p.audit(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := afero.ReadFile(fs, "reports/audit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonAuditReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Passed || report.SchemaVersion != auditReportSchemaVersion || report.Summary[Critical] != 1 || len(report.Findings) != 2 {
+		t.Errorf("unexpected JSON report: %s", b)
+	}
+
+	if f := report.Findings[1]; f.Status != findingIgnored || f.ExceptionReason != "not reachable" {
+		t.Errorf("finding with an exception = %+v", f)
+	}
+
+	b, err = afero.ReadFile(fs, "reports/audit.sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sarif sarifLog
+	if err := json.Unmarshal(b, &sarif); err != nil {
+		t.Fatal(err)
+	}
+
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log: %s", b)
+	}
+
+	run := sarif.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Fatalf("unexpected SARIF run: %s", b)
+	}
+
+	if r := run.Results[0]; r.RuleID != "GHSA-xvch-5gv4-984h" || r.Level != "error" || len(r.Suppressions) != 0 {
+		t.Errorf("critical result = %+v", r)
+	}
+
+	if r := run.Results[1]; r.Level != "warning" || len(r.Suppressions) != 1 {
+		t.Errorf("moderate result with an exception = %+v", r)
+	}
+
+	if _, ok := run.Properties["severityLevels"]; !ok {
+		t.Error("SARIF run does not document the severity levels")
+	}
+}
+
+func TestPlugin_audit_ReportOnFailure(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{AuditLevel: High, AuditReport: []string{"audit.json"}})
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=high"})).
+		Return([]byte(testAuditReport), errors.New("exit status 1"))
+
+	if err := p.audit(nil); err == nil {
+		t.Error("audit should fail on a critical advisory")
+	}
+
+	b, err := afero.ReadFile(fs, "audit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonAuditReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Passed || report.Findings[0].Status != findingFailed || report.Findings[1].Status != findingReported {
+		t.Errorf("unexpected JSON report: %s", b)
+	}
+}
+
+func TestAuditReports_NoPathsUnknownSeverity(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{AuditLevel: High})
+
+	findings := []auditFinding{{Package: "minimist", Severity: "unknown", ID: "GHSA-xvch-5gv4-984h"}}
+	results := auditResults(findings, findings, nil, nil)
+
+	b, err := json.Marshal(p.jsonAuditReport(results, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report struct {
+		Findings []map[string]any `json:"findings"`
+	}
+
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths, ok := report.Findings[0]["paths"].([]any); !ok || len(paths) != 0 {
+		t.Errorf("paths = %v, want an empty list", report.Findings[0]["paths"])
+	}
+
+	sarif := sarifReport(results)
+
+	if got := sarif.Runs[0].Results[0].Level; got != "warning" {
+		t.Errorf("SARIF level = %q, want warning", got)
+	}
+
+	if got := sarif.Runs[0].Tool.Driver.Rules[0].DefaultConfiguration["level"]; got != "warning" {
+		t.Errorf("SARIF rule level = %q, want warning", got)
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// npmOmitDev is the first npm supporting --omit=dev, which replaced --production.
+	// https://docs.npmjs.com/cli/v7/commands/npm-audit#omit
+	npmOmitDev = semver.MustParse("7.0.0")
+	// npmWorkspaces is the first npm supporting workspaces.
+	// https://docs.npmjs.com/cli/v7/using-npm/workspaces
+	npmWorkspaces = semver.MustParse("7.0.0")
+	// npmProvenance is the first npm able to publish with provenance.
+	// https://docs.npmjs.com/generating-provenance-statements
+	npmProvenance = semver.MustParse("9.5.0")
+	// npmPackDestination is the first npm supporting npm pack --pack-destination.
+	// https://github.com/npm/cli/releases/tag/v7.18.0
+	npmPackDestination = semver.MustParse("7.18.0")
+	// npmAccessStatus is the first npm with npm access list, get status and set status.
+	// https://docs.npmjs.com/cli/v9/commands/npm-access
+	npmAccessStatus = semver.MustParse("9.0.0")
+)
+
+// npm6VersionField matches a field of the JavaScript object npm 6 prints instead of JSON.
+var npm6VersionField = regexp.MustCompile(`\b(npm|node)'?: '([^']+)'`)
+
+// parseNpmVersion reads the npm and node versions from the npm version output.
+// https://docs.npmjs.com/cli/version
+func parseNpmVersion(out []byte) (version, error) {
+	var v version
+	if err := json.Unmarshal(out, &v); err == nil {
+		return v, nil
+	}
+
+	for _, m := range npm6VersionField.FindAllSubmatch(out, -1) {
+		switch string(m[1]) {
+		case "npm":
+			v.NPM = string(m[2])
+		case "node":
+			v.Node = string(m[2])
+		}
+	}
+
+	if len(v.NPM) == 0 {
+		return v, errors.New("no npm version in response")
+	}
+
+	return v, nil
+}
+
+// capabilities are the features of the installed npm the commands are built for.
+type capabilities struct {
+	// NPM is the installed npm version.
+	NPM string
+
+	// OmitDev uses --omit=dev instead of the deprecated --production.
+	OmitDev bool
+	// Workspaces allows the --workspace and --workspaces flags.
+	Workspaces bool
+	// Provenance allows publishing with --provenance.
+	Provenance bool
+	// PackDestination allows npm pack --pack-destination.
+	PackDestination bool
+	// AccessStatus allows the npm access list, get status and set status commands.
+	AccessStatus bool
+}
+
+// currentCapabilities are assumed until the npm version was verified.
+var currentCapabilities = capabilities{OmitDev: true, Workspaces: true, Provenance: true, PackDestination: true, AccessStatus: true}
+
+// newCapabilities decides the capabilities from the npm version, a version
+// that cannot be parsed is assumed to be current.
+func newCapabilities(npm string) capabilities {
+	c := currentCapabilities
+	c.NPM = npm
+
+	if v, err := semver.NewVersion(npm); err == nil {
+		c.OmitDev = !v.LessThan(npmOmitDev)
+		c.Workspaces = !v.LessThan(npmWorkspaces)
+		c.Provenance = !v.LessThan(npmProvenance)
+		c.PackDestination = !v.LessThan(npmPackDestination)
+		c.AccessStatus = !v.LessThan(npmAccessStatus)
+	} else {
+		log.Debugf("unable to parse npm version %q, assuming a current npm", npm)
+	}
+
+	log.Debugf("npm %s: --omit=dev %s (needs npm %s)", npm, supported(c.OmitDev), npmOmitDev)
+	log.Debugf("npm %s: workspaces %s (needs npm %s)", npm, supported(c.Workspaces), npmWorkspaces)
+	log.Debugf("npm %s: --provenance %s (needs npm %s)", npm, supported(c.Provenance), npmProvenance)
+	log.Debugf("npm %s: --pack-destination %s (needs npm %s)", npm, supported(c.PackDestination), npmPackDestination)
+	log.Debugf("npm %s: access status %s (needs npm %s)", npm, supported(c.AccessStatus), npmAccessStatus)
+
+	return c
+}
+
+// supported describes a capability decision for the logs.
+func supported(ok bool) string {
+	if ok {
+		return "supported"
+	}
+
+	return "not supported"
+}
+
+// capabilities returns the capabilities of the installed npm.
+func (p *plugin) capabilities() capabilities {
+	if p.caps == nil {
+		return currentCapabilities
+	}
+
+	return *p.caps
+}
+
+// checkCapabilities errors when the action or parameters need a newer npm.
+func (p *plugin) checkCapabilities() error {
+	// packing and access always run through npm
+	switch p.config.Action {
+	case PackAction:
+		if !p.capabilities().PackDestination {
+			return fmt.Errorf("the pack action requires npm %s or newer for --pack-destination, found npm %s", npmPackDestination, p.capabilities().NPM)
+		}
+	case AccessAction:
+		if !p.capabilities().AccessStatus {
+			return fmt.Errorf("the access action requires npm %s or newer, found npm %s", npmAccessStatus, p.capabilities().NPM)
+		}
+	}
+
+	// yarn and pnpm manage workspaces themselves
+	if !p.npmPublishes() {
+		return nil
+	}
+
+	if (p.config.Workspaces || len(p.config.Workspace) > 0) && !p.capabilities().Workspaces {
+		return fmt.Errorf("workspaces require npm %s or newer, found npm %s", npmWorkspaces, p.capabilities().NPM)
+	}
+
+	return nil
+}
+
+// checkProvenance errors when the package asks for provenance the installed npm cannot publish.
+func (p *plugin) checkProvenance(np packageJSON) error {
+	if np.PublishConfig.Provenance && !p.capabilities().Provenance {
+		return fmt.Errorf("publishing %s with provenance requires npm %s or newer, found npm %s", np.Name, npmProvenance, p.capabilities().NPM)
+	}
+
+	return nil
+}
+
+// productionFlag returns the flag limiting a command to production dependencies.
+func (p *plugin) productionFlag() string {
+	if p.capabilities().OmitDev {
+		return "--omit=dev"
+	}
+
+	return "--production"
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+func TestParseNpmVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		npm  string
+		node string
+	}{
+		{name: "json", out: `{"npm": "10.8.2", "node": "22.11.0"}`, npm: "10.8.2", node: "22.11.0"},
+		{name: "npm 6", out: "{ npm: '6.14.18',\n  ares: '1.18.1',\n  node: '14.21.3' }", npm: "6.14.18", node: "14.21.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseNpmVersion([]byte(tt.out))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.NPM != tt.npm || v.Node != tt.node {
+				t.Errorf("parseNpmVersion() = %+v, want npm %s node %s", v, tt.npm, tt.node)
+			}
+		})
+	}
+
+	if _, err := parseNpmVersion([]byte("command not found")); err == nil {
+		t.Error("parseNpmVersion() should fail without an npm version")
+	}
+}
+
+func TestNewCapabilities(t *testing.T) {
+	tests := []struct {
+		npm  string
+		want capabilities
+	}{
+		{npm: "6.14.18", want: capabilities{NPM: "6.14.18"}},
+		{npm: "7.17.0", want: capabilities{NPM: "7.17.0", OmitDev: true, Workspaces: true}},
+		{npm: "8.19.4", want: capabilities{NPM: "8.19.4", OmitDev: true, Workspaces: true, PackDestination: true}},
+		{npm: "9.5.0", want: capabilities{NPM: "9.5.0", OmitDev: true, Workspaces: true, Provenance: true, PackDestination: true, AccessStatus: true}},
+		{npm: "next", want: capabilities{NPM: "next", OmitDev: true, Workspaces: true, Provenance: true, PackDestination: true, AccessStatus: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.npm, func(t *testing.T) {
+			if got := newCapabilities(tt.npm); got != tt.want {
+				t.Errorf("newCapabilities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlugin_verifyNpm_WorkspacesUnsupported(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{Workspaces: true})
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"version"})).
+		Return([]byte("{ npm: '6.14.18', node: '14.21.3' }"), nil)
+
+	err := p.verifyNpm()
+	if err == nil || !strings.Contains(err.Error(), "found npm 6.14.18") {
+		t.Errorf("verifyNpm() error = %v, want workspaces unsupported", err)
+	}
+}
+
+func TestPlugin_verifyNpm_ActionUnsupported(t *testing.T) {
+	tests := []struct {
+		action  string
+		npm     string
+		wantErr string
+	}{
+		{action: PackAction, npm: "7.17.0", wantErr: "the pack action requires npm 7.18.0"},
+		{action: PackAction, npm: "8.19.4"},
+		{action: AccessAction, npm: "8.19.4", wantErr: "the access action requires npm 9.0.0"},
+		{action: AccessAction, npm: "9.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action+"@"+tt.npm, func(t *testing.T) {
+			p, mock, _ := createTestPlugin(t, &Config{Action: tt.action})
+
+			mock.EXPECT().
+				RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"version"})).
+				Return([]byte(`{"npm": "`+tt.npm+`", "node": "18.20.0"}`), nil)
+
+			err := p.verifyNpm()
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Errorf("verifyNpm() error = %v", err)
+			}
+
+			if len(tt.wantErr) > 0 && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("verifyNpm() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlugin_audit_Production(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{AuditLevel: "high"})
+
+	caps := newCapabilities("6.14.18")
+	p.caps = &caps
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--production", "--audit-level=high"})).
+		Return([]byte{}, nil)
+
+	if err := p.audit(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_checkProvenance(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{})
+
+	np := packageJSON{Name: "test", PublishConfig: publishConfig{Provenance: true}}
+
+	if err := p.checkProvenance(np); err != nil {
+		t.Errorf("provenance should be allowed before npm is verified: %v", err)
+	}
+
+	caps := newCapabilities("9.4.2")
+	p.caps = &caps
+
+	if err := p.checkProvenance(np); err == nil {
+		t.Error("provenance should require npm 9.5.0")
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// changedOnly reports whether only workspaces changed since a git ref should be released.
+func (p *plugin) changedOnly() bool {
+	return len(p.config.ChangedSince) > 0 || len(p.config.ChangedTagPattern) > 0
+}
+
+// changedRef resolves the git ref to compare against, defaulting to the
+// latest tag matching the configured pattern.
+func (p *plugin) changedRef() (string, error) {
+	if len(p.config.ChangedSince) > 0 {
+		return p.config.ChangedSince, nil
+	}
+
+	// https://git-scm.com/docs/git-describe
+	o, err := p.cli.RunCommandBytes("git", "describe", "--tags", "--abbrev=0", "--match", p.config.ChangedTagPattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to find a tag matching %s: %w", p.config.ChangedTagPattern, err)
+	}
+
+	ref := strings.TrimSpace(string(o))
+	if len(ref) == 0 {
+		return "", fmt.Errorf("no tag matches %s", p.config.ChangedTagPattern)
+	}
+
+	return ref, nil
+}
+
+// changedFiles lists the files changed between the ref and HEAD, relative to the current directory.
+func (p *plugin) changedFiles(ref string) ([]string, error) {
+	// https://git-scm.com/docs/git-diff
+	o, err := p.cli.RunCommandBytes("git", "diff", "--name-only", "--relative", ref, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed since %s: %w", ref, err)
+	}
+
+	var files []string
+
+	for _, f := range strings.Split(string(o), "\n") {
+		if f = strings.TrimSpace(f); len(f) > 0 {
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+// changedWorkspaces filters the workspace directories down to the ones with
+// changed files, along with every workspace that transitively depends on them.
+func (p *plugin) changedWorkspaces(dirs []string) ([]string, error) {
+	ref, err := p.changedRef()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := p.changedFiles(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make([]packageResult, 0, len(dirs))
+	changed := make(map[string]bool)
+
+	var direct []string
+
+	for _, d := range dirs {
+		np, err := p.readPackage(d)
+		if err != nil {
+			return nil, err
+		}
+
+		packages = append(packages, packageResult{Dir: d, Name: np.Name, Package: np})
+
+		if containsFile(d, files) {
+			changed[np.Name] = true
+
+			direct = append(direct, d)
+		}
+	}
+
+	graph := newDependencyGraph(packages)
+
+	var (
+		selected   []string
+		dependents []string
+	)
+
+	for _, r := range packages {
+		switch {
+		case changed[r.Name]:
+			selected = append(selected, r.Dir)
+		case graph.dependsOn(r.Name, changed):
+			selected = append(selected, r.Dir)
+			dependents = append(dependents, r.Dir)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"ref":        ref,
+		"files":      len(files),
+		"changed":    strings.Join(direct, ", "),
+		"dependents": strings.Join(dependents, ", "),
+	}).Info("Workspaces changed since last release")
+
+	return selected, nil
+}
+
+// containsFile reports whether any of the files is inside the directory.
+func containsFile(dir string, files []string) bool {
+	prefix := path.Clean(dir) + "/"
+
+	for _, f := range files {
+		if strings.HasPrefix(f, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_changedWorkspaces(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces:   true,
+		ChangedSince: "origin/main",
+	})
+	writeTestWorkspaces(t, fs, `{"workspaces": ["packages/*"]}`, "packages/tokens", "packages/docs")
+
+	// ui depends on tokens and icons depends on ui, neither changed
+	deps := map[string]string{
+		"packages/ui":    `{"name": "ui", "version": "1.0.0", "dependencies": {"packages/tokens": "^1.0.0"}}`,
+		"packages/icons": `{"name": "icons", "version": "1.0.0", "peerDependencies": {"ui": "^1.0.0"}}`,
+	}
+	for d, pkg := range deps {
+		if err := afero.WriteFile(fs, d+"/package.json", []byte(pkg), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("git"), gomock.Eq([]string{"diff", "--name-only", "--relative", "origin/main", "HEAD"})).
+		Return([]byte("README.md\npackages/tokens/src/colors.json\npackages/tokens-extra.md\n"), nil)
+
+	dirs := []string{"packages/docs", "packages/icons", "packages/tokens", "packages/ui"}
+
+	changed, err := p.changedWorkspaces(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"packages/icons", "packages/tokens", "packages/ui"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changedWorkspaces = %v, want %v", changed, want)
+	}
+}
+
+func TestPlugin_changedWorkspaces_TagPattern(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces:        true,
+		ChangedTagPattern: "v*",
+	})
+	writeTestWorkspaces(t, fs, `{"workspaces": ["packages/*"]}`, "packages/a", "packages/b")
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("git"), gomock.Eq([]string{"describe", "--tags", "--abbrev=0", "--match", "v*"})).
+		Return([]byte("v1.2.0\n"), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("git"), gomock.Eq([]string{"diff", "--name-only", "--relative", "v1.2.0", "HEAD"})).
+		Return([]byte("packages/b/index.js\n"), nil)
+
+	changed, err := p.changedWorkspaces([]string{"packages/a", "packages/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"packages/b"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changedWorkspaces = %v, want %v", changed, want)
+	}
+}
+
+func TestPlugin_changedWorkspaces_NoTag(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Workspaces:        true,
+		ChangedTagPattern: "release-*",
+	})
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("git"), gomock.Eq([]string{"describe", "--tags", "--abbrev=0", "--match", "release-*"})).
+		Return(nil, errors.New("Process exited with status code 128"))
+
+	_, err := p.changedWorkspaces([]string{"packages/a"})
+	if err == nil {
+		t.Error("changedWorkspaces should have failed without a matching tag")
+	}
+}
@@ -4,6 +4,9 @@ package npm
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"path"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -12,22 +15,45 @@ import (
 
 // Config inputs.
 type Config struct {
-	Token           string
-	UserName        string
-	Password        string
-	Registry        string
-	Email           string
-	StrictSSL       bool
-	IsStrictSSLSet  bool
-	AlwaysAuth      bool
-	IsAlwaysAuthSet bool
-	SkipPing        bool
-	DryRun          bool
-	Tag             string
-	AuditLevel      string
-	Access          string
-	Workspaces      bool
-	Workspace       string
+	Token             string
+	UserName          string
+	Password          string
+	Registry          string
+	Email             string
+	StrictSSL         bool
+	IsStrictSSLSet    bool
+	AlwaysAuth        bool
+	IsAlwaysAuthSet   bool
+	SkipPing          bool
+	DryRun            bool
+	Tag               string
+	AuditLevel        string
+	Access            string
+	Workspaces        bool
+	Workspace         string
+	Action            string
+	Package           string
+	VersionRange      string
+	Message           string
+	Confirm           string
+	PackDestination   string
+	Tarball           string
+	Grants            []string
+	Remove            bool
+	SkipExisting      bool
+	ChangedSince      string
+	ChangedTagPattern string
+	WorkspaceInclude  []string
+	WorkspaceExclude  []string
+	RegistryTokens    []string
+	Parallelism       int
+	NpmVersion        string
+	AuditReport       []string
+	LicenseAllow      []string
+	LicenseDeny       []string
+	LicenseMode       string
+	LockfileCheck     bool
+	AllowedRegistries []string
 }
 
 const (
@@ -43,12 +69,36 @@ const (
 	None = "none"
 )
 
+const (
+	// PublishAction publishes the package or workspaces.
+	PublishAction = "publish"
+	// VerifyAction runs the pre-publish checks without publishing.
+	VerifyAction = "verify"
+	// DistTagAction adds or removes a dist-tag on a published version.
+	DistTagAction = "dist-tag"
+	// InfoAction reports registry information about packages.
+	InfoAction = "info"
+	// DeprecateAction deprecates published versions of a package.
+	DeprecateAction = "deprecate"
+	// UnpublishAction removes a published version of a package.
+	UnpublishAction = "unpublish"
+	// PackAction creates tarballs of the package or workspaces without publishing.
+	PackAction = "pack"
+	// AccessAction manages package visibility and team grants.
+	AccessAction = "access"
+)
+
 // DefaultRegistry is the default URL for npm.
 const DefaultRegistry = "https://registry.npmjs.org"
 
 // Validate assures plugin is configured correctly.
 func (p *Config) Validate() error {
-	if len(p.Token) == 0 {
+	if err := p.validateAction(); err != nil {
+		return err
+	}
+
+	// packing does not communicate with the registry
+	if len(p.Token) == 0 && p.Action != PackAction {
 		if len(p.UserName) == 0 {
 			return errors.New("UserName not provided")
 		}
@@ -63,6 +113,34 @@ func (p *Config) Validate() error {
 		log.Infof("Registry not provided, using default registry %s", DefaultRegistry)
 	}
 
+	for _, t := range p.RegistryTokens {
+		if _, _, err := parseRegistryToken(t); err != nil {
+			return err
+		}
+	}
+
+	if len(p.NpmVersion) != 0 {
+		if _, err := semver.NewConstraint(p.NpmVersion); err != nil {
+			return fmt.Errorf("npm_version must be a version or semver range: %w", err)
+		}
+	}
+
+	for _, r := range p.AuditReport {
+		if len(auditReportFormat(r)) == 0 {
+			return fmt.Errorf("audit_report %s must end in .sarif or .json", r)
+		}
+	}
+
+	for _, r := range p.AllowedRegistries {
+		if u, err := url.Parse(r); err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			return fmt.Errorf("allowed_registries %s must be an http or https URL", r)
+		}
+	}
+
+	if err := p.validateLicensePolicy(); err != nil {
+		return err
+	}
+
 	if len(p.Email) == 0 {
 		log.Warn("Email not provied")
 	}
@@ -80,27 +158,13 @@ func (p *Config) Validate() error {
 		}
 	}
 
-	switch strings.ToLower(p.AuditLevel) {
-	case "l", "low", "all":
-		p.AuditLevel = Low
-	case "m", "mod", "moderate":
-		p.AuditLevel = Moderate
-	case "h", "high":
-		p.AuditLevel = High
-	case "c", "crit", "critical":
-		p.AuditLevel = Critical
-	case "n", "no", "none":
-		p.AuditLevel = None
-	default:
-		log.Warn("audit_level is not recognized, setting to None")
-
+	// only publish and verify run the audit
+	if p.Action == PublishAction || p.Action == VerifyAction {
+		p.validateAuditLevel()
+	} else {
 		p.AuditLevel = None
 	}
 
-	log.WithFields(log.Fields{
-		"audit-level": p.AuditLevel,
-	}).Debug("audit level set")
-
 	// access should be 'restricted' or 'public"
 	// https://docs.npmjs.com/cli/v8/commands/npm-publish#access
 	if len(p.Access) != 0 {
@@ -117,5 +181,169 @@ func (p *Config) Validate() error {
 		return errors.New("you must either specify a workspace or all workspaces, but not both")
 	}
 
+	// changed workspaces are selected from the resolved workspaces
+	if (len(p.ChangedSince) > 0 || len(p.ChangedTagPattern) > 0) && !p.Workspaces {
+		return errors.New("changed_since requires workspaces to be enabled")
+	}
+
+	if p.Parallelism < 0 {
+		return errors.New("parallelism must not be negative")
+	}
+
+	if err := p.validateWorkspaceFilters(); err != nil {
+		return err
+	}
+
+	if p.SkipExisting && len(p.Tarball) > 0 {
+		return errors.New("skip_existing cannot be combined with tarball")
+	}
+
+	// a tarball already contains a single built package
+	if len(p.Tarball) > 0 && (p.Workspaces || len(p.Workspace) > 0) {
+		return errors.New("tarball cannot be combined with workspaces")
+	}
+
+	return nil
+}
+
+// validateAction assures the inputs for the selected action are correct.
+func (p *Config) validateAction() error {
+	switch strings.ToLower(p.Action) {
+	case "", PublishAction:
+		p.Action = PublishAction
+	case VerifyAction:
+		p.Action = VerifyAction
+	case InfoAction:
+		p.Action = InfoAction
+	case DistTagAction:
+		p.Action = DistTagAction
+
+		if len(p.Tag) == 0 {
+			return errors.New("tag must be provided to update a dist-tag")
+		}
+	case DeprecateAction:
+		p.Action = DeprecateAction
+
+		if len(p.VersionRange) == 0 {
+			return errors.New("version_range must be provided to deprecate")
+		}
+
+		if _, err := semver.NewConstraint(p.VersionRange); err != nil {
+			return fmt.Errorf("version_range is not a valid semver range: %w", err)
+		}
+
+		if len(p.Message) == 0 {
+			log.Warn("No deprecation message provided, matching versions will be un-deprecated")
+		}
+	case UnpublishAction:
+		p.Action = UnpublishAction
+
+		if err := p.validateUnpublish(); err != nil {
+			return err
+		}
+	case PackAction:
+		p.Action = PackAction
+
+		if len(p.PackDestination) == 0 {
+			p.PackDestination = "."
+		}
+	case AccessAction:
+		p.Action = AccessAction
+
+		if len(p.Access) == 0 && len(p.Grants) == 0 {
+			return errors.New("access or grants must be provided to manage access")
+		}
+
+		for _, g := range p.Grants {
+			if _, err := parseGrant(g); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("action %s is not recognized", p.Action)
+	}
+
+	log.WithFields(log.Fields{
+		"action": p.Action,
+	}).Debug("action set")
+
+	return nil
+}
+
+// validateUnpublish assures only a single version is unpublished unless
+// the confirmation matches the package name.
+func (p *Config) validateUnpublish() error {
+	if len(p.Package) == 0 {
+		return errors.New("package must be provided as name@version to unpublish")
+	}
+
+	name, version := splitPackageSpec(p.Package)
+	if len(name) == 0 {
+		return fmt.Errorf("package %s is not a valid name@version", p.Package)
+	}
+
+	if len(version) > 0 {
+		if _, err := semver.StrictNewVersion(version); err == nil {
+			return nil
+		}
+
+		if _, err := semver.NewConstraint(version); err != nil {
+			return fmt.Errorf("package version %s is not a valid version or range: %w", version, err)
+		}
+	}
+
+	// unpublishing a range or the whole package requires confirmation
+	if p.Confirm != name {
+		return fmt.Errorf("unpublishing a range or an entire package requires confirm to be set to %s", name)
+	}
+
+	log.WithFields(log.Fields{
+		"package": p.Package,
+	}).Warn("Unpublish of multiple versions confirmed")
+
 	return nil
 }
+
+// validateLicensePolicy checks the license patterns and defaults the license mode to fail.
+func (p *Config) validateLicensePolicy() error {
+	for _, pattern := range append(append([]string{}, p.LicenseAllow...), p.LicenseDeny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("license pattern %s is invalid: %w", pattern, err)
+		}
+	}
+
+	switch strings.ToLower(p.LicenseMode) {
+	case "", LicenseModeFail:
+		p.LicenseMode = LicenseModeFail
+	case LicenseModeWarn:
+		p.LicenseMode = LicenseModeWarn
+	default:
+		return fmt.Errorf("license_mode %s is not recognized, use '%s' or '%s'", p.LicenseMode, LicenseModeFail, LicenseModeWarn)
+	}
+
+	return nil
+}
+
+// validateAuditLevel normalizes the audit level, unknown levels skip the audit.
+func (p *Config) validateAuditLevel() {
+	switch strings.ToLower(p.AuditLevel) {
+	case "l", "low", "all":
+		p.AuditLevel = Low
+	case "m", "mod", "moderate":
+		p.AuditLevel = Moderate
+	case "h", "high":
+		p.AuditLevel = High
+	case "c", "crit", "critical":
+		p.AuditLevel = Critical
+	case "n", "no", "none":
+		p.AuditLevel = None
+	default:
+		log.Warn("audit_level is not recognized, setting to None")
+
+		p.AuditLevel = None
+	}
+
+	log.WithFields(log.Fields{
+		"audit-level": p.AuditLevel,
+	}).Debug("audit level set")
+}
@@ -147,3 +147,240 @@ func TestConfig_Validate_Workspace(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestConfig_Validate_Deprecate(t *testing.T) {
+	c := &Config{
+		UserName:     "testuser",
+		Action:       "deprecate",
+		VersionRange: "<1.2.0",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfig_Validate_Deprecate_NoRange(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "deprecate",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_Action_NotRecognized(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "explode",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_Unpublish_Version(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "unpublish",
+		Package:  "@go-vela/vela-npm@1.0.0",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfig_Validate_Unpublish_RangeNotConfirmed(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "unpublish",
+		Package:  "@go-vela/vela-npm@^1.0.0",
+		Confirm:  "vela-npm",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_Unpublish_PackageConfirmed(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "unpublish",
+		Package:  "@go-vela/vela-npm",
+		Confirm:  "@go-vela/vela-npm",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfig_Validate_Pack_NoCredentials(t *testing.T) {
+	c := &Config{
+		Action: "pack",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if c.PackDestination != "." {
+		t.Error("PackDestination not defaulted")
+	}
+}
+
+func TestConfig_Validate_Tarball_Workspaces(t *testing.T) {
+	c := &Config{
+		UserName:   "testuser",
+		Tarball:    "dist/*.tgz",
+		Workspaces: true,
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_Access_NothingDeclared(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "access",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_DistTag_NoTag(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   "dist-tag",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_ChangedSince_NoWorkspaces(t *testing.T) {
+	c := &Config{
+		UserName:     "testuser",
+		ChangedSince: "v1.0.0",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_NpmVersion(t *testing.T) {
+	c := &Config{
+		UserName:   "testuser",
+		NpmVersion: "ten",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_LicensePolicy(t *testing.T) {
+	c := &Config{
+		UserName:    "testuser",
+		LicenseDeny: []string{"GPL-*"},
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.LicenseMode != LicenseModeFail {
+		t.Errorf("LicenseMode = %s, want %s", c.LicenseMode, LicenseModeFail)
+	}
+
+	c.LicenseMode = "ignore"
+	if err := p.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown license_mode")
+	}
+
+	c.LicenseMode = LicenseModeWarn
+	c.LicenseAllow = []string{"[MIT"}
+
+	if err := p.Validate(); err == nil {
+		t.Error("Validate() should reject an invalid license pattern")
+	}
+}
+
+func TestConfig_Validate_AllowedRegistries(t *testing.T) {
+	c := &Config{
+		UserName:          "testuser",
+		AllowedRegistries: []string{"registry.npmjs.org"},
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestConfig_Validate_AuditLevel_OtherAction(t *testing.T) {
+	c := &Config{
+		UserName: "testuser",
+		Action:   InfoAction,
+		Package:  "@go-vela/vela-npm",
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.AuditLevel != None {
+		t.Errorf("AuditLevel = %s, want %s for the info action", c.AuditLevel, None)
+	}
+}
+
+func TestConfig_Validate_Parallelism_Negative(t *testing.T) {
+	c := &Config{
+		UserName:    "testuser",
+		Parallelism: -1,
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil || err.Error() != "parallelism must not be negative" {
+		t.Errorf("Validate() = %v, want the negative parallelism error", err)
+	}
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// deprecatePlugin deprecates published versions of packages.
+type deprecatePlugin struct {
+	*plugin
+}
+
+// Exec runs the deprecate plugin.
+func (p *deprecatePlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	return p.deprecate()
+}
+
+// deprecate marks every registry version of the target packages matching the
+// version range as deprecated, an empty message will un-deprecate them.
+// https://docs.npmjs.com/cli/commands/npm-deprecate
+func (p *plugin) deprecate() error {
+	constraint, err := semver.NewConstraint(p.config.VersionRange)
+	if err != nil {
+		return fmt.Errorf("version_range is not a valid semver range: %w", err)
+	}
+
+	names, err := p.targetPackages()
+	if err != nil {
+		return err
+	}
+
+	action := "deprecated"
+	if len(p.config.Message) == 0 {
+		action = "un-deprecated"
+	}
+
+	for _, name := range names {
+		versions, err := p.matchingVersions(name, constraint)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) == 0 {
+			log.WithFields(log.Fields{
+				"package": name,
+				"range":   p.config.VersionRange,
+			}).Warn("No published versions match the range")
+
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"package":  name,
+			"range":    p.config.VersionRange,
+			"versions": strings.Join(versions, ", "),
+		}).Info("Versions that will be " + action)
+
+		if p.config.DryRun {
+			log.Info("Doing a dry run, versions will not be " + action)
+
+			continue
+		}
+
+		for _, v := range versions {
+			_, err := p.cli.RunCommandBytes("npm", "deprecate", name+"@"+v, p.config.Message, "--registry", p.config.Registry)
+			if err != nil {
+				return fmt.Errorf("failed to deprecate %s@%s: %w", name, v, err)
+			}
+
+			log.Debug(name + "@" + v + " successfully " + action)
+		}
+
+		log.WithFields(log.Fields{
+			"package": name,
+			"count":   len(versions),
+		}).Info("Successfully " + action + " versions")
+	}
+
+	return nil
+}
+
+// matchingVersions returns the published versions of a package that satisfy the constraint.
+func (p *plugin) matchingVersions(name string, constraint *semver.Constraints) ([]string, error) {
+	versions, err := p.packageVersions(name, p.config.Registry)
+	if err != nil {
+		if errors.Is(err, errPackageNotFound) {
+			return nil, fmt.Errorf("package %s does not exist in the registry", name)
+		}
+
+		return nil, err
+	}
+
+	var matches []string
+
+	for _, v := range versions {
+		sv, err := semver.NewVersion(v)
+		if err != nil {
+			log.Tracef("skipping unparsable version %s", v)
+
+			continue
+		}
+
+		if constraint.Check(sv) {
+			matches = append(matches, v)
+		}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_deprecate(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:      "vela-npm",
+		VersionRange: ">=1.1.0 <2.0.0",
+		Message:      "broken release",
+		Registry:     "http://registry.test.com",
+	})
+	res := `["1.0.0", "1.1.0", "1.2.0", "2.0.0"]`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"deprecate", "vela-npm@1.1.0", "broken release", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"deprecate", "vela-npm@1.2.0", "broken release", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err := p.deprecate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_deprecate_Undeprecate(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		VersionRange: "1.0.0",
+		Registry:     "http://registry.test.com",
+	})
+
+	err := afero.WriteFile(fs, "package.json", []byte(`{"name": "vela-npm", "version": "1.1.0"}`), 0644)
+	if err != nil {
+		t.Fail()
+	}
+
+	res := `["1.0.0", "1.1.0"]`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"deprecate", "vela-npm@1.0.0", "", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err = p.deprecate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_deprecate_DryRun(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:      "vela-npm",
+		VersionRange: "^1.0.0",
+		Message:      "broken release",
+		DryRun:       true,
+		Registry:     "http://registry.test.com",
+	})
+	res := `["1.0.0", "1.1.0"]`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Any()).
+		Times(0)
+
+	err := p.deprecate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_deprecate_PackageNotFound(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:      "vela-npm",
+		VersionRange: "^1.0.0",
+		Registry:     "http://registry.test.com",
+	})
+	res := `{
+		"error": {
+			"code": "E404",
+			"summary": "Not Found - GET https://registry.npmjs.org/vela-npm - not_found",
+			"detail": ""
+		}
+	}`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), errors.New("Process exited with status code 1"))
+
+	err := p.deprecate()
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// distTagPlugin adds or removes dist-tags on published versions.
+type distTagPlugin struct {
+	*plugin
+}
+
+// Exec runs the dist-tag plugin.
+func (p *distTagPlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	return p.distTag()
+}
+
+// distTag adds the tag to a published version or removes it from the package.
+// https://docs.npmjs.com/cli/commands/npm-dist-tag
+func (p *plugin) distTag() error {
+	name, version := splitPackageSpec(p.config.Package)
+
+	// default to the version in the current package.json
+	if len(name) == 0 {
+		np, err := p.readPackage(".")
+		if err != nil {
+			return err
+		}
+
+		name, version = np.Name, np.Version
+	}
+
+	var args []string
+
+	if p.config.Remove {
+		args = []string{"dist-tag", "rm", name, p.config.Tag}
+	} else {
+		if len(version) == 0 {
+			return errors.New("package must be provided as name@version to add a dist-tag")
+		}
+
+		args = []string{"dist-tag", "add", name + "@" + version, p.config.Tag}
+	}
+
+	log.WithFields(log.Fields{
+		"package": name,
+		"version": version,
+		"tag":     p.config.Tag,
+		"remove":  p.config.Remove,
+	}).Info("Updating dist-tag")
+
+	if p.config.DryRun {
+		log.Info("Doing a dry run, dist-tag will not be updated")
+
+		return nil
+	}
+
+	args = append(args, "--registry", p.config.Registry)
+
+	if _, err := p.cli.RunCommandBytes("npm", args...); err != nil {
+		return fmt.Errorf("dist-tag failed: %w", err)
+	}
+
+	log.Info("Successfully updated dist-tag!")
+
+	return nil
+}
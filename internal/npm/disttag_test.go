@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_distTag_Add(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Tag:      "beta",
+		Registry: "http://registry.test.com",
+	})
+
+	err := afero.WriteFile(fs, "package.json", []byte(`{"name": "vela-npm", "version": "1.1.0"}`), 0644)
+	if err != nil {
+		t.Fail()
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"dist-tag", "add", "vela-npm@1.1.0", "beta", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err = p.distTag()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_distTag_Remove(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "@go-vela/vela-npm",
+		Tag:      "beta",
+		Remove:   true,
+		Registry: "http://registry.test.com",
+	})
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"dist-tag", "rm", "@go-vela/vela-npm", "beta", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err := p.distTag()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_distTag_AddNoVersion(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{
+		Package:  "vela-npm",
+		Tag:      "beta",
+		Registry: "http://registry.test.com",
+	})
+
+	err := p.distTag()
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// auditExceptionsFile lists the advisories the audit ignores until they expire.
+const auditExceptionsFile = ".vela-npm-audit.json"
+
+// auditExceptionDate is the format of the expiry dates.
+const auditExceptionDate = "2006-01-02"
+
+// auditException ignores an advisory that does not apply until its expiry date.
+type auditException struct {
+	ID      string `json:"id"`
+	Reason  string `json:"reason"`
+	Expires string `json:"expires"`
+
+	expires time.Time
+}
+
+// expired reports whether the exception no longer applies, exceptions are valid through their expiry date.
+func (e auditException) expired(now time.Time) bool {
+	return !now.Before(e.expires.AddDate(0, 0, 1))
+}
+
+// readAuditExceptions reads the audit exceptions of the project, returning none when it has no exceptions file.
+func (p *plugin) readAuditExceptions() ([]auditException, error) {
+	if ok, _ := p.os.Exists(auditExceptionsFile); !ok {
+		return nil, nil
+	}
+
+	b, err := p.os.ReadFile(auditExceptionsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", auditExceptionsFile, err)
+	}
+
+	var file struct {
+		Exceptions []auditException `json:"exceptions"`
+	}
+
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", auditExceptionsFile, err)
+	}
+
+	var errs []error
+
+	for i, e := range file.Exceptions {
+		if len(e.ID) == 0 {
+			errs = append(errs, fmt.Errorf("exception %d has no id", i+1))
+
+			continue
+		}
+
+		if len(strings.TrimSpace(e.Reason)) == 0 {
+			errs = append(errs, fmt.Errorf("exception %s has no reason", e.ID))
+		}
+
+		expires, err := time.Parse(auditExceptionDate, e.Expires)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("exception %s must expire on a date in the form YYYY-MM-DD", e.ID))
+		}
+
+		file.Exceptions[i].expires = expires
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", auditExceptionsFile, err)
+	}
+
+	return file.Exceptions, nil
+}
+
+// applyAuditExceptions drops the findings ignored by an exception that has not
+// expired, and reports the exceptions that are expired or match no finding.
+func applyAuditExceptions(findings []auditFinding, exceptions []auditException, now time.Time) []auditFinding {
+	if len(exceptions) == 0 {
+		return findings
+	}
+
+	var remaining []auditFinding
+
+	used := make(map[int]bool, len(exceptions))
+
+	for _, f := range findings {
+		ignored := false
+
+		for i, e := range exceptions {
+			if !strings.EqualFold(e.ID, f.ID) {
+				continue
+			}
+
+			used[i] = true
+
+			if !e.expired(now) {
+				ignored = true
+			}
+		}
+
+		if !ignored {
+			remaining = append(remaining, f)
+		}
+	}
+
+	for i, e := range exceptions {
+		entry := log.WithFields(log.Fields{
+			"id":      e.ID,
+			"reason":  e.Reason,
+			"expires": e.Expires,
+		})
+
+		switch {
+		case e.expired(now) && used[i]:
+			entry.Warn("Audit exception expired, the advisory is no longer ignored")
+		case e.expired(now):
+			entry.Warn("Audit exception expired and matches no advisory, remove it from " + auditExceptionsFile)
+		case !used[i]:
+			entry.Warn("Audit exception matches no advisory, remove it from " + auditExceptionsFile)
+		default:
+			entry.Info("Ignoring advisory with an audit exception")
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_readAuditExceptions_Invalid(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+
+	exceptions := `{"exceptions": [{"id": "GHSA-xvch-5gv4-984h", "expires": "next year"}, {"reason": "no id"}]}`
+	if err := afero.WriteFile(fs, auditExceptionsFile, []byte(exceptions), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := p.readAuditExceptions()
+	if err == nil {
+		t.Fatal("readAuditExceptions() should fail on invalid exceptions")
+	}
+
+	for _, want := range []string{"has no reason", "YYYY-MM-DD", "exception 2 has no id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestApplyAuditExceptions(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	date := func(s string) time.Time {
+		d, _ := time.Parse(auditExceptionDate, s)
+
+		return d
+	}
+
+	findings := []auditFinding{
+		{Package: "minimist", Severity: Critical, ID: "GHSA-xvch-5gv4-984h"},
+		{Package: "semver", Severity: High, ID: "GHSA-c2qf-rxjj-qqgw"},
+		{Package: "lodash", Severity: High, ID: "GHSA-35jh-r3h4-6jhm"},
+	}
+
+	exceptions := []auditException{
+		// expires at the end of today, still ignored
+		{ID: "ghsa-xvch-5gv4-984h", Reason: "not reachable", expires: date("2026-06-15")},
+		{ID: "GHSA-c2qf-rxjj-qqgw", Reason: "expired", expires: date("2026-06-14")},
+		{ID: "GHSA-unused", Reason: "fixed upstream", expires: date("2027-01-01")},
+	}
+
+	got := applyAuditExceptions(findings, exceptions, now)
+	if len(got) != 2 || got[0].ID != "GHSA-c2qf-rxjj-qqgw" || got[1].ID != "GHSA-35jh-r3h4-6jhm" {
+		t.Errorf("applyAuditExceptions() = %+v", got)
+	}
+}
+
+func TestPlugin_audit_Exceptions(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{AuditLevel: Moderate})
+
+	exceptions := `{"exceptions": [
+		{"id": "GHSA-xvch-5gv4-984h", "reason": "only used at build time", "expires": "2099-01-01"},
+		{"id": "GHSA-c2qf-rxjj-qqgw", "reason": "no fix yet", "expires": "2020-01-01"}
+	]}`
+	if err := afero.WriteFile(fs, auditExceptionsFile, []byte(exceptions), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=moderate"})).
+		Return([]byte(testAuditReport), errors.New("exit status 1"))
+
+	err := p.audit(nil)
+	if err == nil || !strings.Contains(err.Error(), "GHSA-c2qf-rxjj-qqgw") {
+		t.Fatalf("audit should fail on the advisory with an expired exception: %v", err)
+	}
+
+	if strings.Contains(err.Error(), "GHSA-xvch-5gv4-984h") {
+		t.Errorf("audit should ignore the advisory with an exception: %v", err)
+	}
+}
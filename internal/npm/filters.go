@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// hasWorkspaceFilters reports whether workspace_include or workspace_exclude are set.
+func (p *Config) hasWorkspaceFilters() bool {
+	return len(p.WorkspaceInclude) > 0 || len(p.WorkspaceExclude) > 0
+}
+
+// validateWorkspaceFilters assures every filter is a valid pattern.
+func (p *Config) validateWorkspaceFilters() error {
+	if !p.hasWorkspaceFilters() {
+		return nil
+	}
+
+	if !p.Workspaces {
+		return errors.New("workspace_include and workspace_exclude require workspaces to be enabled")
+	}
+
+	for _, f := range append(append([]string{}, p.WorkspaceInclude...), p.WorkspaceExclude...) {
+		for _, seg := range strings.Split(cleanPattern(f), "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("workspace filter %s is not a valid pattern: %w", f, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// workspaceFilter is a workspace directory with the name of its package.
+type workspaceFilter struct {
+	Dir  string
+	Name string
+}
+
+// filterWorkspaces keeps the workspaces matching workspace_include that do
+// not match workspace_exclude, by package name or directory.
+func (p *plugin) filterWorkspaces(dirs []string) ([]string, error) {
+	if !p.config.hasWorkspaceFilters() {
+		return dirs, nil
+	}
+
+	workspaces, err := p.workspaceNames(dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		selected []string
+		excluded []string
+	)
+
+	for _, w := range workspaces {
+		if len(p.config.WorkspaceInclude) > 0 && !w.matches(p.config.WorkspaceInclude) {
+			excluded = append(excluded, w.Dir)
+
+			continue
+		}
+
+		if w.matches(p.config.WorkspaceExclude) {
+			excluded = append(excluded, w.Dir)
+
+			continue
+		}
+
+		selected = append(selected, w.Dir)
+	}
+
+	log.WithFields(log.Fields{
+		"selected": strings.Join(selected, ", "),
+		"excluded": strings.Join(excluded, ", "),
+	}).Info("Filtered workspace directories")
+
+	return selected, nil
+}
+
+// unmatchedWorkspaceFilters returns the filters that match none of the workspaces.
+func (p *plugin) unmatchedWorkspaceFilters(dirs []string) ([]string, error) {
+	workspaces, err := p.workspaceNames(dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	var unmatched []string
+
+	for _, f := range append(append([]string{}, p.config.WorkspaceInclude...), p.config.WorkspaceExclude...) {
+		found := false
+
+		for _, w := range workspaces {
+			if w.matches([]string{f}) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			unmatched = append(unmatched, f)
+		}
+	}
+
+	return unmatched, nil
+}
+
+// workspaceNames reads the package name of every workspace directory.
+func (p *plugin) workspaceNames(dirs []string) ([]workspaceFilter, error) {
+	workspaces := make([]workspaceFilter, 0, len(dirs))
+
+	for _, d := range dirs {
+		np, err := p.readPackage(d)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, workspaceFilter{Dir: d, Name: np.Name})
+	}
+
+	return workspaces, nil
+}
+
+// matches reports whether the workspace directory or package name matches any of the patterns.
+func (w workspaceFilter) matches(patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = cleanPattern(pattern)
+
+		if matchPath(pattern, w.Dir) || (len(w.Name) > 0 && matchPath(pattern, w.Name)) {
+			return true
+		}
+	}
+
+	return false
+}
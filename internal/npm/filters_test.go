@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testScopedWorkspaces = map[string]string{
+	"packages/ui":             `{"name": "@corp/ui", "version": "1.0.0"}`,
+	"packages/tokens":         `{"name": "@corp/tokens", "version": "1.0.0"}`,
+	"packages/internal-lint":  `{"name": "@corp/internal-lint", "version": "1.0.0"}`,
+	"packages/internal-build": `{"name": "@corp/internal-build", "version": "1.0.0"}`,
+}
+
+func TestPlugin_checkForWorkspaces_Filters(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{
+		Workspaces:       true,
+		WorkspaceInclude: []string{"@corp/*", "./packages/tokens"},
+		WorkspaceExclude: []string{"@corp/internal-*", "packages/tokens"},
+	})
+	writeTestPackages(t, fs, `{"workspaces": ["packages/*"]}`, testScopedWorkspaces)
+
+	workspaces, err := p.checkForWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"packages/ui"}
+	if !reflect.DeepEqual(workspaces, want) {
+		t.Errorf("checkForWorkspaces = %v, want %v", workspaces, want)
+	}
+}
+
+func TestPlugin_checkForWorkspaces_ExcludeOnly(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{
+		Workspaces:       true,
+		WorkspaceExclude: []string{"@corp/internal-*"},
+	})
+	writeTestPackages(t, fs, `{"workspaces": ["packages/*"]}`, testScopedWorkspaces)
+
+	workspaces, err := p.checkForWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"packages/tokens", "packages/ui"}
+	if !reflect.DeepEqual(workspaces, want) {
+		t.Errorf("checkForWorkspaces = %v, want %v", workspaces, want)
+	}
+}
+
+func TestPlugin_Validate_UnmatchedFilter(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{
+		UserName:         "testuser",
+		Workspaces:       true,
+		WorkspaceInclude: []string{"@corp/ui"},
+		WorkspaceExclude: []string{"@crop/internal-*"},
+	})
+	writeTestPackages(t, fs, `{"workspaces": ["packages/*"]}`, testScopedWorkspaces)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("Validate should have failed on a filter matching nothing")
+	}
+
+	want := "workspace filters match no workspaces: @crop/internal-*"
+	if err.Error() != want {
+		t.Errorf("Validate error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestConfig_Validate_FiltersWithoutWorkspaces(t *testing.T) {
+	c := &Config{
+		UserName:         "testuser",
+		WorkspaceInclude: []string{"@corp/*"},
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
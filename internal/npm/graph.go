@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dependencyGraph maps each package to the packages in the same release it depends on.
+type dependencyGraph map[string][]string
+
+// newDependencyGraph builds the graph of runtime dependencies between the given packages.
+func newDependencyGraph(results []packageResult) dependencyGraph {
+	names := make(map[string]bool, len(results))
+	for _, r := range results {
+		names[r.Name] = true
+	}
+
+	graph := make(dependencyGraph, len(results))
+
+	for _, r := range results {
+		graph[r.Name] = nil
+
+		for _, dep := range r.Package.runtimeDependencies() {
+			if names[dep] && dep != r.Name {
+				graph[r.Name] = append(graph[r.Name], dep)
+			}
+		}
+	}
+
+	return graph
+}
+
+// publishOrder sorts the packages so that each one comes after its
+// dependencies, keeping the original order where there is no dependency.
+func publishOrder(results []packageResult) ([]packageResult, error) {
+	graph := newDependencyGraph(results)
+
+	done := make(map[string]bool, len(results))
+	ordered := make([]packageResult, 0, len(results))
+
+	for len(ordered) < len(results) {
+		progress := false
+
+		for _, r := range results {
+			if done[r.Name] || !allDone(graph[r.Name], done) {
+				continue
+			}
+
+			done[r.Name] = true
+			ordered = append(ordered, r)
+			progress = true
+		}
+
+		if !progress {
+			return nil, fmt.Errorf("dependency cycle detected between workspaces: %s", strings.Join(graph.cycle(done), " -> "))
+		}
+	}
+
+	return ordered, nil
+}
+
+// allDone reports whether every dependency has been ordered.
+func allDone(deps []string, done map[string]bool) bool {
+	for _, d := range deps {
+		if !done[d] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cycle walks the remaining packages until one repeats, returning the cycle.
+func (g dependencyGraph) cycle(done map[string]bool) []string {
+	var start string
+
+	for name := range g {
+		if !done[name] && (len(start) == 0 || name < start) {
+			start = name
+		}
+	}
+
+	var path []string
+
+	seen := make(map[string]int)
+
+	name := start
+
+	for {
+		if i, ok := seen[name]; ok {
+			return append(path[i:], name)
+		}
+
+		seen[name] = len(path)
+		path = append(path, name)
+
+		// every remaining package has a remaining dependency, otherwise it would be done
+		next := ""
+
+		for _, d := range g[name] {
+			if !done[d] {
+				next = d
+
+				break
+			}
+		}
+
+		if len(next) == 0 {
+			return path
+		}
+
+		name = next
+	}
+}
+
+// dependsOn reports whether the package depends, directly or transitively, on any of the targets.
+func (g dependencyGraph) dependsOn(name string, targets map[string]bool) bool {
+	seen := make(map[string]bool)
+	queue := append([]string{}, g[name]...)
+
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+
+		if targets[d] {
+			return true
+		}
+
+		if seen[d] {
+			continue
+		}
+
+		seen[d] = true
+		queue = append(queue, g[d]...)
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+func TestPlugin_publishOrder(t *testing.T) {
+	results := []packageResult{
+		{Name: "@corp/ui", Package: packageJSON{Dependencies: map[string]string{"@corp/tokens": "^1.0.0", "react": "^18.0.0"}}},
+		{Name: "@corp/icons", Package: packageJSON{PeerDependencies: map[string]string{"@corp/ui": "^1.0.0"}}},
+		{Name: "@corp/tokens"},
+	}
+
+	ordered, err := publishOrder(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"@corp/tokens", "@corp/ui", "@corp/icons"}
+	for i, r := range ordered {
+		if r.Name != want[i] {
+			t.Errorf("publishOrder[%d] = %s, want %s", i, r.Name, want[i])
+		}
+	}
+}
+
+func TestPlugin_publishOrder_Cycle(t *testing.T) {
+	results := []packageResult{
+		{Name: "a", Package: packageJSON{Dependencies: map[string]string{"b": "1.0.0"}}},
+		{Name: "b", Package: packageJSON{OptionalDependencies: map[string]string{"a": "1.0.0"}}},
+		{Name: "c"},
+	}
+
+	_, err := publishOrder(results)
+	if err == nil {
+		t.Fatal("publishOrder should have failed on a dependency cycle")
+	}
+
+	want := "dependency cycle detected between workspaces: a -> b -> a"
+	if err.Error() != want {
+		t.Errorf("publishOrder error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestPlugin_publishSelected_DependencyFailed(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Registry: "http://registry.test.com",
+	})
+	results := []packageResult{
+		{Dir: "packages/ui", Name: "@corp/ui", Version: "1.1.0", Status: statusPending, Package: packageJSON{Dependencies: map[string]string{"@corp/tokens": "^1.1.0"}}},
+		{Dir: "packages/tokens", Name: "@corp/tokens", Version: "1.1.0", Status: statusPending},
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "packages/tokens", "--registry", "http://registry.test.com"})).
+		Return(nil, errors.New("Process exited with status code 1"))
+
+	err := p.publishSelected(results)
+	if err == nil {
+		t.Error("publishSelected should have failed")
+	}
+
+	if results[0].Status != statusFailed || results[1].Status != statusFailed {
+		t.Errorf("unexpected results %v", results)
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// infoPlugin reports what the registry knows about packages.
+type infoPlugin struct {
+	*plugin
+}
+
+type packageInfo struct {
+	Name     string            `json:"name"`
+	DistTags map[string]string `json:"dist-tags"`
+	Versions []string          `json:"versions"`
+	Time     map[string]string `json:"time"`
+}
+
+// Exec runs the info plugin.
+func (p *infoPlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	return p.info()
+}
+
+// info logs the dist-tags and versions of each target package in the registry.
+// https://docs.npmjs.com/cli/commands/npm-view
+func (p *plugin) info() error {
+	names, err := p.targetPackages()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		out, cmdErr := p.cli.RunCommandBytes("npm", "view", name, "--json", "--registry", p.config.Registry)
+		if cmdErr != nil {
+			err := npmViewError(out, cmdErr)
+			if errors.Is(err, errPackageNotFound) {
+				log.WithFields(log.Fields{"package": name}).Info("Package is not published")
+
+				continue
+			}
+
+			return err
+		}
+
+		var res packageInfo
+		if err := json.Unmarshal(out, &res); err != nil {
+			return fmt.Errorf("failed to convert npm view response: %w", err)
+		}
+
+		tags := make([]string, 0, len(res.DistTags))
+		for t, v := range res.DistTags {
+			tags = append(tags, t+"="+v)
+		}
+
+		sort.Strings(tags)
+
+		log.WithFields(log.Fields{
+			"package":   name,
+			"dist-tags": strings.Join(tags, ", "),
+			"versions":  len(res.Versions),
+			"modified":  res.Time["modified"],
+		}).Info("Package info")
+	}
+
+	return nil
+}
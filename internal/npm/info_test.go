@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+func TestPlugin_info(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "vela-npm",
+		Registry: "http://registry.test.com",
+	})
+	res := `{
+		"name": "vela-npm",
+		"dist-tags": {"latest": "1.1.0", "beta": "1.2.0-beta.1"},
+		"versions": ["1.0.0", "1.1.0", "1.2.0-beta.1"],
+		"time": {"modified": "2020-01-03T00:00:00.000Z"}
+	}`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+
+	err := p.info()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_info_NotPublished(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "vela-npm",
+		Registry: "http://registry.test.com",
+	})
+	res := `{"error": {"code": "E404", "summary": "Not Found", "detail": ""}}`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), errors.New("Process exited with status code 1"))
+
+	err := p.info()
+	if err != nil {
+		t.Error(err)
+	}
+}
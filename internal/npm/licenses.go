@@ -0,0 +1,409 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// LicenseModeFail fails the release when a dependency violates the license policy.
+	LicenseModeFail = "fail"
+	// LicenseModeWarn only warns about dependencies violating the license policy.
+	LicenseModeWarn = "warn"
+)
+
+// license is the license field of a package.json, an SPDX expression or the
+// deprecated {"type": "MIT", "url": "..."} object.
+// https://docs.npmjs.com/cli/configuring-npm/package-json#license
+type license string
+
+// UnmarshalJSON reads the license from either form, other forms are treated as no license.
+func (l *license) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = license(s)
+
+		return nil
+	}
+
+	var obj struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(b, &obj); err == nil {
+		*l = license(obj.Type)
+	}
+
+	return nil
+}
+
+// license returns the license of the package, combining the deprecated licenses
+// array as alternatives.
+func (np packageJSON) license() license {
+	if len(np.License) > 0 || len(np.Licenses) == 0 {
+		return np.License
+	}
+
+	ids := make([]string, 0, len(np.Licenses))
+	for _, l := range np.Licenses {
+		ids = append(ids, string(l))
+	}
+
+	return license("(" + strings.Join(ids, " OR ") + ")")
+}
+
+// licenseExpr is a parsed SPDX license expression.
+// https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/
+type licenseExpr struct {
+	// Op is AND or OR, or empty for a single license.
+	Op    string
+	ID    string
+	Terms []licenseExpr
+}
+
+// parseLicense parses an SPDX expression, an expression that cannot be parsed
+// is treated as a single license, e.g. "SEE LICENSE IN LICENSE.md".
+func parseLicense(expr string) licenseExpr {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+
+	parser := &licenseParser{tokens: tokens}
+
+	parsed, ok := parser.or()
+	if !ok || parser.pos != len(tokens) {
+		return licenseExpr{ID: strings.TrimSpace(expr)}
+	}
+
+	return parsed
+}
+
+// licenseParser is a recursive descent parser for SPDX expressions, where AND
+// binds tighter than OR.
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token in upper case.
+func (lp *licenseParser) peek() string {
+	if lp.pos >= len(lp.tokens) {
+		return ""
+	}
+
+	return strings.ToUpper(lp.tokens[lp.pos])
+}
+
+func (lp *licenseParser) or() (licenseExpr, bool) {
+	return lp.binary("OR", lp.and)
+}
+
+func (lp *licenseParser) and() (licenseExpr, bool) {
+	return lp.binary("AND", lp.primary)
+}
+
+// binary parses terms joined by the operator.
+func (lp *licenseParser) binary(op string, term func() (licenseExpr, bool)) (licenseExpr, bool) {
+	first, ok := term()
+	if !ok {
+		return first, false
+	}
+
+	terms := []licenseExpr{first}
+
+	for lp.peek() == op {
+		lp.pos++
+
+		next, ok := term()
+		if !ok {
+			return next, false
+		}
+
+		terms = append(terms, next)
+	}
+
+	if len(terms) == 1 {
+		return first, true
+	}
+
+	return licenseExpr{Op: op, Terms: terms}, true
+}
+
+// primary parses a parenthesized expression or a license with an optional exception.
+func (lp *licenseParser) primary() (licenseExpr, bool) {
+	switch lp.peek() {
+	case "", ")", "AND", "OR", "WITH":
+		return licenseExpr{}, false
+	case "(":
+		lp.pos++
+
+		inner, ok := lp.or()
+		if !ok || lp.peek() != ")" {
+			return inner, false
+		}
+
+		lp.pos++
+
+		return inner, true
+	}
+
+	id := lp.tokens[lp.pos]
+	lp.pos++
+
+	if lp.peek() == "WITH" {
+		lp.pos++
+
+		if next := lp.peek(); len(next) == 0 || next == "(" || next == ")" {
+			return licenseExpr{}, false
+		}
+
+		id += " WITH " + lp.tokens[lp.pos]
+		lp.pos++
+	}
+
+	return licenseExpr{ID: id}, true
+}
+
+// licensePolicy decides which licenses dependencies may use.
+type licensePolicy struct {
+	// Allow lists the permitted licenses, when empty every license not denied is permitted.
+	Allow []string
+	// Deny lists the forbidden licenses, taking precedence over Allow.
+	Deny []string
+}
+
+// allows reports whether the expression can be satisfied, every license joined
+// by AND must be permitted and at least one of those joined by OR.
+func (lp licensePolicy) allows(expr licenseExpr) bool {
+	switch expr.Op {
+	case "AND":
+		for _, t := range expr.Terms {
+			if !lp.allows(t) {
+				return false
+			}
+		}
+
+		return true
+	case "OR":
+		for _, t := range expr.Terms {
+			if lp.allows(t) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if licenseMatches(lp.Deny, expr.ID) {
+		return false
+	}
+
+	return len(lp.Allow) == 0 || licenseMatches(lp.Allow, expr.ID)
+}
+
+// licenseMatches reports whether a license matches one of the patterns, e.g.
+// GPL-* matches GPL-3.0-only. A license with an exception or "or later" suffix
+// also matches the patterns of the license itself.
+func licenseMatches(patterns []string, id string) bool {
+	candidates := []string{id}
+
+	base, _, _ := strings.Cut(id, " WITH ")
+	candidates = append(candidates, base, strings.TrimSuffix(base, "+"))
+
+	for _, pattern := range patterns {
+		for _, c := range candidates {
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(c)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// licenseViolation is a dependency whose license the policy does not permit.
+type licenseViolation struct {
+	Package string
+	Version string
+	License string
+	// Path is the dependency path leading to the package, e.g. app > mkdirp > minimist.
+	Path string
+}
+
+// checkLicenses evaluates the license of every production dependency of the
+// project, or only of the given workspaces, against the license policy.
+func (p *plugin) checkLicenses(workspaces []string) error {
+	policy := licensePolicy{Allow: p.config.LicenseAllow, Deny: p.config.LicenseDeny}
+	if len(policy.Allow) == 0 && len(policy.Deny) == 0 {
+		return nil
+	}
+
+	log.Info("Running license check")
+
+	starts := []string{""}
+	if len(workspaces) > 0 {
+		starts = make([]string, 0, len(workspaces))
+		for _, w := range workspaces {
+			starts = append(starts, path.Clean(w))
+		}
+	}
+
+	packages, err := p.dependencyTree(starts)
+	if errors.Is(err, errUnresolvedTree) && p.config.LicenseMode == LicenseModeWarn {
+		log.Warnf("%v, skipping the license check because license_mode is %s", err, LicenseModeWarn)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	violations := licenseViolations(packages, dependencyPaths(packages, starts), policy)
+
+	if len(violations) == 0 {
+		log.Info("License check found no violations")
+
+		return nil
+	}
+
+	for _, v := range violations {
+		log.WithFields(log.Fields{
+			"package": v.Package,
+			"version": v.Version,
+			"license": v.License,
+			"path":    v.Path,
+		}).Warn("Dependency violates the license policy")
+	}
+
+	if p.config.LicenseMode == LicenseModeWarn {
+		log.Warnf("%d dependencies violate the license policy, continuing because license_mode is %s", len(violations), LicenseModeWarn)
+
+		return nil
+	}
+
+	offenders := make([]string, 0, len(violations))
+	for _, v := range violations {
+		offenders = append(offenders, fmt.Sprintf("%s@%s (%s) via %s", v.Package, v.Version, v.License, v.Path))
+	}
+
+	return fmt.Errorf("%d dependencies violate the license policy: %s", len(violations), strings.Join(offenders, ", "))
+}
+
+// errUnresolvedTree is returned when the installed dependencies cannot be read or walked.
+var errUnresolvedTree = errors.New("the license check cannot resolve the dependencies")
+
+// dependencyTree returns the installed packages from package-lock.json, with
+// licenses it does not record read from node_modules, falling back to the
+// package.json files in node_modules for projects without one.
+func (p *plugin) dependencyTree(starts []string) (map[string]lockPackage, error) {
+	lock, err := p.readPackageLock()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnresolvedTree, err)
+	}
+
+	if lock != nil {
+		p.installedLicenses(lock.Packages)
+
+		return lock.Packages, nil
+	}
+
+	if reason := p.unresolvedTree(); len(reason) > 0 {
+		return nil, fmt.Errorf("%w: %s", errUnresolvedTree, reason)
+	}
+
+	log.Debugf("no %s found, reading licenses from node_modules", packageLockFile)
+
+	packages, err := p.installedPackages(starts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnresolvedTree, err)
+	}
+
+	return packages, nil
+}
+
+// unresolvedTree explains why node_modules cannot be walked for the dependency
+// tree, or returns an empty string when it can.
+func (p *plugin) unresolvedTree() string {
+	if ok, _ := p.os.DirExists(path.Join("node_modules", ".pnpm")); ok || p.manager == pnpmManager {
+		return "pnpm links every dependency from node_modules/.pnpm, so transitive dependencies cannot be walked without a " + packageLockFile
+	}
+
+	if ok, _ := p.os.DirExists("node_modules"); ok {
+		return ""
+	}
+
+	if p.manager == yarnManager {
+		return "Yarn Plug'n'Play installs no node_modules, set `nodeLinker: node-modules` in .yarnrc.yml to check licenses"
+	}
+
+	return fmt.Sprintf("the project has no %s or node_modules, run `npm install` before the license check", packageLockFile)
+}
+
+// installedLicenses reads the license of every lockfile entry that does not
+// record one from its package.json in node_modules.
+func (p *plugin) installedLicenses(packages map[string]lockPackage) {
+	for loc, pkg := range packages {
+		if !inNodeModules(loc) || pkg.Link || len(pkg.License) > 0 {
+			continue
+		}
+
+		np, err := p.readPackage(loc)
+		if err != nil {
+			log.Debugf("no license for %s: %v", loc, err)
+
+			continue
+		}
+
+		pkg.License = np.license()
+		packages[loc] = pkg
+	}
+}
+
+// licenseViolations returns the dependencies the policy does not permit, a
+// dependency without a license is only a violation when licenses are allowed explicitly.
+func licenseViolations(packages map[string]lockPackage, paths map[string][]string, policy licensePolicy) []licenseViolation {
+	var violations []licenseViolation
+
+	for loc, depPath := range paths {
+		pkg := packages[loc]
+
+		name := pkg.Name
+		if len(name) == 0 {
+			name = lockPackageName(loc)
+		}
+
+		id := strings.TrimSpace(string(pkg.License))
+
+		switch {
+		case len(id) == 0 && len(policy.Allow) == 0:
+			continue
+		case len(id) == 0:
+			id = "none"
+		case policy.allows(parseLicense(id)):
+			continue
+		}
+
+		violations = append(violations, licenseViolation{
+			Package: name,
+			Version: pkg.Version,
+			License: id,
+			Path:    strings.Join(depPath, " > "),
+		})
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Package != violations[j].Package {
+			return violations[i].Package < violations[j].Package
+		}
+
+		return violations[i].Path < violations[j].Path
+	})
+
+	return violations
+}
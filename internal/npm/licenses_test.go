@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLicensePolicy_allows(t *testing.T) {
+	policy := licensePolicy{Allow: []string{"MIT", "Apache-2.0", "BSD-*"}, Deny: []string{"GPL-*", "AGPL-*"}}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "MIT", want: true},
+		{expr: "mit", want: true},
+		{expr: "BSD-3-Clause", want: true},
+		{expr: "GPL-3.0-only", want: false},
+		{expr: "GPL-2.0+", want: false},
+		{expr: "ISC", want: false},
+		{expr: "(MIT OR GPL-3.0-only)", want: true},
+		{expr: "MIT AND GPL-3.0-only", want: false},
+		{expr: "MIT AND (Apache-2.0 OR AGPL-3.0-only)", want: true},
+		{expr: "MIT OR ISC AND GPL-3.0-only", want: true},
+		{expr: "Apache-2.0 WITH LLVM-exception", want: true},
+		{expr: "GPL-2.0-only WITH Classpath-exception-2.0", want: false},
+		{expr: "SEE LICENSE IN LICENSE.md", want: false},
+		{expr: "(MIT OR", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := policy.allows(parseLicense(tt.expr)); got != tt.want {
+				t.Errorf("allows(%s) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	if !(licensePolicy{Deny: []string{"GPL-*"}}).allows(parseLicense("LGPL-2.1-only")) {
+		t.Error("a deny list only should allow every license it does not match")
+	}
+}
+
+func TestParseLicense(t *testing.T) {
+	got := parseLicense("MIT OR (Apache-2.0 AND BSD-2-Clause)")
+
+	if got.Op != "OR" || len(got.Terms) != 2 || got.Terms[0].ID != "MIT" || got.Terms[1].Op != "AND" {
+		t.Errorf("parseLicense() = %+v", got)
+	}
+
+	if got := parseLicense("(MIT OR"); got.ID != "(MIT OR" {
+		t.Errorf("parseLicense() = %+v, want a single license", got)
+	}
+}
+
+func TestPlugin_checkLicenses(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		dirs    []string
+		wantErr string
+	}{
+		{
+			name:    "denied",
+			config:  Config{LicenseDeny: []string{"GPL-*"}, LicenseMode: LicenseModeFail},
+			wantErr: "1 dependencies violate the license policy: minimist@0.2.4 (GPL-3.0-only) via root > mkdirp > minimist",
+		},
+		{
+			name:   "warn",
+			config: Config{LicenseDeny: []string{"GPL-*"}, LicenseMode: LicenseModeWarn},
+		},
+		{
+			name:    "missing license",
+			config:  Config{LicenseAllow: []string{"MIT", "GPL-*"}, LicenseMode: LicenseModeFail},
+			dirs:    []string{"packages/app"},
+			wantErr: "lib@1.0.0 (none) via app > lib",
+		},
+		{
+			name:   "no policy",
+			config: Config{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, fs := createTestPlugin(t, &tt.config)
+
+			if err := afero.WriteFile(fs, packageLockFile, []byte(testPackageLock), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := p.checkLicenses(tt.dirs)
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Errorf("checkLicenses() error = %v", err)
+			}
+
+			if len(tt.wantErr) > 0 && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkLicenses() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlugin_checkLicenses_UnresolvedTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		manager string
+		files   []string
+		lock    string
+		mode    string
+		wantErr string
+	}{
+		{name: "lockfile v1", manager: npmManager, lock: `{"lockfileVersion": 1}`, mode: LicenseModeFail, wantErr: "lockfileVersion 2 or 3"},
+		{name: "lockfile v1 warn", manager: npmManager, lock: `{"lockfileVersion": 1}`, mode: LicenseModeWarn},
+		{name: "pnpm", manager: pnpmManager, files: []string{"node_modules/.pnpm/lock.yaml"}, mode: LicenseModeFail, wantErr: "node_modules/.pnpm"},
+		{name: "yarn pnp", manager: yarnManager, files: []string{".pnp.cjs"}, mode: LicenseModeFail, wantErr: "Plug'n'Play"},
+		{name: "not installed", manager: npmManager, mode: LicenseModeFail, wantErr: "run `npm install`"},
+		{name: "warn", manager: pnpmManager, mode: LicenseModeWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, fs := createTestPlugin(t, &Config{LicenseDeny: []string{"GPL-*"}, LicenseMode: tt.mode})
+			p.manager = tt.manager
+
+			for _, f := range append(tt.files, "package.json") {
+				if err := afero.WriteFile(fs, f, []byte(`{"name": "app"}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(tt.lock) > 0 {
+				if err := afero.WriteFile(fs, packageLockFile, []byte(tt.lock), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := p.checkLicenses(nil)
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Errorf("checkLicenses() error = %v", err)
+			}
+
+			if len(tt.wantErr) > 0 && (!errors.Is(err, errUnresolvedTree) || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkLicenses() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlugin_checkLicenses_InstalledLicense(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{LicenseDeny: []string{"AGPL-*"}, LicenseMode: LicenseModeFail})
+
+	lock := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "dependencies": {"left-pad": "^1.3.0"}},
+    "node_modules/left-pad": {"version": "1.3.0"}
+  }
+}`
+
+	files := map[string]string{
+		packageLockFile:                      lock,
+		"node_modules/left-pad/package.json": `{"name": "left-pad", "version": "1.3.0", "license": "AGPL-3.0-only"}`,
+	}
+
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := p.checkLicenses(nil)
+	if err == nil || !strings.Contains(err.Error(), "left-pad@1.3.0 (AGPL-3.0-only) via app > left-pad") {
+		t.Errorf("checkLicenses() error = %v, want the license from node_modules", err)
+	}
+}
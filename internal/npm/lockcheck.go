@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// lockViolation is a problem with a single entry of the lockfile.
+type lockViolation struct {
+	Package string
+	// Location is where the package is installed, e.g. node_modules/a/node_modules/b.
+	Location string
+	Problem  string
+}
+
+// checkLockfile makes sure package-lock.json matches the package.json files of
+// the project and only installs packages with an integrity hash from an allowed registry.
+func (p *plugin) checkLockfile() error {
+	if !p.config.LockfileCheck {
+		return nil
+	}
+
+	if !p.npmPublishes() {
+		log.Warnf("lockfile_check only checks %s, skipping it for %s", packageLockFile, p.manager)
+
+		return nil
+	}
+
+	log.Info("Running lockfile check")
+
+	lock, err := p.readPackageLock()
+	if err != nil {
+		return err
+	}
+
+	if lock == nil {
+		return fmt.Errorf("lockfile_check requires a %s, run `npm install` to create it", packageLockFile)
+	}
+
+	registries := p.allowedRegistries()
+
+	log.WithField("registries", strings.Join(registries, ", ")).Debug("Allowed lockfile registries")
+
+	violations, err := p.lockSyncViolations(lock.Packages)
+	if err != nil {
+		return err
+	}
+
+	violations = append(violations, lockSourceViolations(lock.Packages, registries)...)
+
+	if len(violations) == 0 {
+		log.Infof("%s is in sync with package.json and every package comes from an allowed registry", packageLockFile)
+
+		return nil
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Package != violations[j].Package {
+			return violations[i].Package < violations[j].Package
+		}
+
+		return violations[i].Location < violations[j].Location
+	})
+
+	byPackage := make(map[string][]string)
+
+	var packages []string
+
+	for _, v := range violations {
+		log.WithFields(log.Fields{
+			"package":  v.Package,
+			"location": v.Location,
+		}).Warn(v.Problem)
+
+		if _, ok := byPackage[v.Package]; !ok {
+			packages = append(packages, v.Package)
+		}
+
+		byPackage[v.Package] = append(byPackage[v.Package], v.Problem)
+	}
+
+	problems := make([]string, 0, len(packages))
+	for _, name := range packages {
+		problems = append(problems, fmt.Sprintf("%s: %s", name, strings.Join(byPackage[name], ", ")))
+	}
+
+	return fmt.Errorf("%s failed the lockfile check for %d packages: %s", packageLockFile, len(packages), strings.Join(problems, "; "))
+}
+
+// allowedRegistries returns the allowed_registries parameter, or the registries
+// the plugin is configured with when it is not set.
+func (p *plugin) allowedRegistries() []string {
+	if len(p.config.AllowedRegistries) > 0 {
+		return p.config.AllowedRegistries
+	}
+
+	registry := p.config.Registry
+	if len(registry) == 0 {
+		registry = DefaultRegistry
+	}
+
+	registries := []string{registry}
+
+	for _, t := range p.config.RegistryTokens {
+		if r, _, err := parseRegistryToken(t); err == nil {
+			registries = append(registries, r)
+		}
+	}
+
+	return appendUnique(registries, p.publishRegistries()...)
+}
+
+// lockSyncViolations compares the dependencies declared by the package.json of
+// the root package and every workspace with the packages installed by the lockfile.
+func (p *plugin) lockSyncViolations(packages map[string]lockPackage) ([]lockViolation, error) {
+	var violations []lockViolation
+
+	// the root package and the workspaces are the only entries outside node_modules
+	var locs []string
+
+	for loc := range packages {
+		if !inNodeModules(loc) {
+			locs = append(locs, loc)
+		}
+	}
+
+	sort.Strings(locs)
+
+	for _, loc := range locs {
+		np, err := p.readPackage(lockLocation(loc))
+		if err != nil {
+			return nil, err
+		}
+
+		name := np.Name
+		if len(name) == 0 {
+			name = lockLocation(loc)
+		}
+
+		declared := []struct {
+			deps     map[string]string
+			optional bool
+		}{
+			{deps: np.Dependencies},
+			{deps: np.DevDependencies},
+			{deps: np.OptionalDependencies, optional: true},
+			{deps: np.PeerDependencies, optional: true},
+		}
+
+		for _, d := range declared {
+			for _, dep := range sortedKeys(d.deps) {
+				if problem := lockDependencyProblem(packages, loc, dep, d.deps[dep], d.optional); len(problem) > 0 {
+					violations = append(violations, lockViolation{Package: name, Location: lockLocation(loc), Problem: problem})
+				}
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// lockDependencyProblem checks that a declared dependency is installed by the
+// lockfile at a version satisfying its range, optional dependencies may be missing.
+func lockDependencyProblem(packages map[string]lockPackage, from, name, spec string, optional bool) string {
+	loc, ok := resolveLockDependency(packages, from, name)
+	if !ok {
+		if optional {
+			return ""
+		}
+
+		return fmt.Sprintf("%s@%s is not in %s, run `npm install` to update it", name, spec, packageLockFile)
+	}
+
+	installed := packages[loc]
+
+	// workspaces are installed as links to their directory
+	if installed.Link {
+		installed = packages[installed.Resolved]
+	}
+
+	// links to directories outside the project have no version to compare
+	if len(installed.Version) == 0 {
+		return ""
+	}
+
+	// aliases are declared as npm:name@range
+	if alias, ok := strings.CutPrefix(spec, "npm:"); ok {
+		if i := strings.LastIndex(alias, "@"); i > 0 {
+			spec = alias[i+1:]
+		}
+	}
+
+	// tags, git, file and workspace ranges cannot be compared with the installed version
+	constraint, err := semver.NewConstraint(strings.TrimPrefix(spec, workspaceProtocol))
+	if err != nil {
+		return ""
+	}
+
+	version, err := semver.NewVersion(installed.Version)
+	if err != nil || !constraint.Check(version) {
+		return fmt.Sprintf("%s@%s is locked at version %s, run `npm install` to update it", name, spec, installed.Version)
+	}
+
+	return ""
+}
+
+// lockSourceViolations checks that every installed package has an integrity
+// hash and was resolved from an allowed registry.
+func lockSourceViolations(packages map[string]lockPackage, registries []string) []lockViolation {
+	var violations []lockViolation
+
+	for _, loc := range sortedKeys(packages) {
+		pkg := packages[loc]
+
+		// links point at workspaces and bundled packages come inside their parent's tarball
+		if !inNodeModules(loc) || pkg.Link || pkg.InBundle {
+			continue
+		}
+
+		name := pkg.Name
+		if len(name) == 0 {
+			name = lockPackageName(loc)
+		}
+
+		if len(pkg.Integrity) == 0 {
+			violations = append(violations, lockViolation{Package: name, Location: loc, Problem: "has no integrity hash"})
+		}
+
+		switch {
+		case len(pkg.Resolved) == 0:
+			violations = append(violations, lockViolation{Package: name, Location: loc, Problem: "has no resolved URL"})
+		case strings.HasPrefix(pkg.Resolved, "file:"):
+			// local tarballs are part of the repository
+		case !allowedRegistry(pkg.Resolved, registries):
+			violations = append(violations, lockViolation{
+				Package:  name,
+				Location: loc,
+				Problem:  fmt.Sprintf("is resolved from %s which is not an allowed registry", pkg.Resolved),
+			})
+		}
+	}
+
+	return violations
+}
+
+// allowedRegistry reports whether the resolved URL is on the host of one of the
+// registries and below its path.
+func allowedRegistry(resolved string, registries []string) bool {
+	u, err := url.Parse(resolved)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+
+	for _, r := range registries {
+		allowed, err := url.Parse(normalizeRegistry(r))
+		if err != nil {
+			continue
+		}
+
+		if !strings.EqualFold(u.Host, allowed.Host) {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(allowed.Path, "/")
+		if len(prefix) == 0 || u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inNodeModules reports whether the location is an installed package, rather
+// than the root package or a workspace.
+func inNodeModules(loc string) bool {
+	return strings.HasPrefix(loc, "node_modules/") || strings.Contains(loc, "/node_modules/")
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_checkLockfile(t *testing.T) {
+	lock := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "dependencies": {"mkdirp": "^0.5.5", "left-pad": "^1.3.0"}},
+    "node_modules/mkdirp": {
+      "version": "0.5.5",
+      "resolved": "https://registry.npmjs.org/mkdirp/-/mkdirp-0.5.5.tgz",
+      "integrity": "sha512-mkdirp",
+      "dependencies": {"minimist": "^1.2.5"}
+    },
+    "node_modules/minimist": {
+      "version": "1.2.8",
+      "resolved": "https://npm.evil.test.com/minimist/-/minimist-1.2.8.tgz"
+    },
+    "node_modules/left-pad": {
+      "version": "1.3.0",
+      "resolved": "https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz",
+      "integrity": "sha512-left-pad"
+    }
+  }
+}`
+
+	tests := []struct {
+		name    string
+		config  Config
+		pkg     string
+		wantErr []string
+	}{
+		{
+			name:   "disabled",
+			config: Config{},
+			pkg:    `{"name": "app", "dependencies": {"mkdirp": "^1.0.0"}}`,
+		},
+		{
+			name:   "allowed registries",
+			config: Config{LockfileCheck: true, AllowedRegistries: []string{"https://registry.npmjs.org/", "https://npm.evil.test.com"}},
+			pkg:    `{"name": "app", "dependencies": {"mkdirp": "^0.5.5", "left-pad": "^1.3.0"}, "optionalDependencies": {"fsevents": "^2.0.0"}}`,
+			wantErr: []string{
+				"failed the lockfile check for 1 packages",
+				"minimist: has no integrity hash",
+			},
+		},
+		{
+			name:   "out of sync",
+			config: Config{LockfileCheck: true, Registry: "https://registry.npmjs.org"},
+			pkg:    `{"name": "app", "dependencies": {"mkdirp": "^1.0.0", "chalk": "^5.0.0", "left-pad": "latest"}}`,
+			wantErr: []string{
+				"failed the lockfile check for 2 packages",
+				"app: chalk@^5.0.0 is not in package-lock.json, run `npm install` to update it, mkdirp@^1.0.0 is locked at version 0.5.5",
+				"minimist: has no integrity hash, is resolved from https://npm.evil.test.com/minimist/-/minimist-1.2.8.tgz which is not an allowed registry",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, fs := createTestPlugin(t, &tt.config)
+			p.manager = npmManager
+
+			if err := afero.WriteFile(fs, packageLockFile, []byte(lock), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := afero.WriteFile(fs, "package.json", []byte(tt.pkg), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := p.checkLockfile()
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Fatalf("checkLockfile() error = %v", err)
+			}
+
+			for _, want := range tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("checkLockfile() error = %v, want %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlugin_checkLockfile_Missing(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{LockfileCheck: true})
+	p.manager = npmManager
+
+	if err := p.checkLockfile(); err == nil || !strings.Contains(err.Error(), "requires a package-lock.json") {
+		t.Errorf("checkLockfile() error = %v, want missing lockfile", err)
+	}
+}
+
+func TestAllowedRegistry(t *testing.T) {
+	registries := []string{"https://registry.npmjs.org/", "https://artifacts.corp.test.com/api/npm/npm-remote"}
+
+	tests := []struct {
+		resolved string
+		want     bool
+	}{
+		{resolved: "https://registry.npmjs.org/a/-/a-1.0.0.tgz", want: true},
+		{resolved: "https://REGISTRY.npmjs.org/a/-/a-1.0.0.tgz", want: true},
+		{resolved: "https://artifacts.corp.test.com/api/npm/npm-remote/a/-/a-1.0.0.tgz", want: true},
+		{resolved: "https://artifacts.corp.test.com/api/npm/npm-other/a/-/a-1.0.0.tgz", want: false},
+		{resolved: "https://registry.npmjs.org.evil.test.com/a/-/a-1.0.0.tgz", want: false},
+		{resolved: "git+ssh://git@github.com/a/a.git#abc123", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resolved, func(t *testing.T) {
+			if got := allowedRegistry(tt.resolved, registries); got != tt.want {
+				t.Errorf("allowedRegistry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// packageLockFile is the npm lockfile.
+// https://docs.npmjs.com/cli/configuring-npm/package-lock-json
+const packageLockFile = "package-lock.json"
+
+// packageLock is a package-lock.json with lockfileVersion 2 or 3.
+type packageLock struct {
+	LockfileVersion int `json:"lockfileVersion"`
+	// Packages are keyed by their location relative to the project, "" is the root package.
+	Packages map[string]lockPackage `json:"packages"`
+}
+
+// lockPackage is a package installed at a location of the project.
+type lockPackage struct {
+	Name      string  `json:"name"`
+	Version   string  `json:"version"`
+	Resolved  string  `json:"resolved"`
+	Integrity string  `json:"integrity"`
+	Link      bool    `json:"link"`
+	Dev       bool    `json:"dev"`
+	InBundle  bool    `json:"inBundle"`
+	License   license `json:"license"`
+
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// runtimeDependencies returns the names of the dependencies installed alongside the package.
+func (l lockPackage) runtimeDependencies() []string {
+	var names []string
+
+	for _, deps := range []map[string]string{l.Dependencies, l.OptionalDependencies, l.PeerDependencies} {
+		for name := range deps {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// readPackageLock reads package-lock.json, returning nil when the project has none.
+func (p *plugin) readPackageLock() (*packageLock, error) {
+	if ok, _ := p.os.Exists(packageLockFile); !ok {
+		return nil, nil
+	}
+
+	b, err := p.os.ReadFile(packageLockFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", packageLockFile, err)
+	}
+
+	var lock packageLock
+	if err := json.Unmarshal(b, &lock); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", packageLockFile, err)
+	}
+
+	if lock.LockfileVersion < 2 {
+		return nil, fmt.Errorf("%s has lockfileVersion %d, lockfileVersion 2 or 3 is required, run `npm install` with npm 7 or newer to upgrade it",
+			packageLockFile, lock.LockfileVersion)
+	}
+
+	return &lock, nil
+}
+
+// installedPackages reads the package.json of the given directories and of every
+// package in their node_modules, keyed by location like the packages of package-lock.json.
+func (p *plugin) installedPackages(dirs []string) (map[string]lockPackage, error) {
+	packages := make(map[string]lockPackage)
+
+	var walk func(dir string) error
+
+	walk = func(dir string) error {
+		entries, err := p.os.ReadDir(path.Join(dir, "node_modules"))
+		if err != nil {
+			// packages without dependencies have no node_modules
+			return nil
+		}
+
+		var names []string
+
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+
+			// scoped packages are one directory deeper
+			if !strings.HasPrefix(e.Name(), "@") {
+				names = append(names, e.Name())
+
+				continue
+			}
+
+			scoped, err := p.os.ReadDir(path.Join(dir, "node_modules", e.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to read node_modules: %w", err)
+			}
+
+			for _, s := range scoped {
+				names = append(names, e.Name()+"/"+s.Name())
+			}
+		}
+
+		for _, name := range names {
+			loc := path.Join(dir, "node_modules", name)
+
+			np, err := p.readPackage(loc)
+			if err != nil {
+				log.Debugf("skipping %s: %v", loc, err)
+
+				continue
+			}
+
+			packages[loc] = packageLockEntry(np)
+
+			// linked workspaces are walked from their own directory
+			if p.isSymlink(loc) {
+				continue
+			}
+
+			if err := walk(loc); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, dir := range dirs {
+		np, err := p.readPackage(lockLocation(dir))
+		if err != nil {
+			return nil, err
+		}
+
+		packages[dir] = packageLockEntry(np)
+
+		if err := walk(lockLocation(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	return packages, nil
+}
+
+// isSymlink reports whether the path is a symbolic link, e.g. a workspace linked into node_modules.
+func (p *plugin) isSymlink(name string) bool {
+	lstater, ok := p.os.Fs.(afero.Lstater)
+	if !ok {
+		return false
+	}
+
+	info, _, err := lstater.LstatIfPossible(name)
+
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// lockLocation converts a package location, where "" is the root package, to a directory.
+func lockLocation(loc string) string {
+	if len(loc) == 0 {
+		return "."
+	}
+
+	return loc
+}
+
+// packageLockEntry converts an installed package.json to a lockfile entry.
+func packageLockEntry(np packageJSON) lockPackage {
+	return lockPackage{
+		Name:                 np.Name,
+		Version:              np.Version,
+		License:              np.license(),
+		Dependencies:         np.Dependencies,
+		OptionalDependencies: np.OptionalDependencies,
+		PeerDependencies:     np.PeerDependencies,
+	}
+}
+
+// lockPackageName returns the package name installed at a location, e.g. node_modules/@scope/a is @scope/a.
+func lockPackageName(loc string) string {
+	if i := strings.LastIndex(loc, "node_modules/"); i >= 0 {
+		return loc[i+len("node_modules/"):]
+	}
+
+	return path.Base(loc)
+}
+
+// resolveLockDependency finds where a dependency required from a location is
+// installed, looking in the node_modules of the location and then of every parent
+// like node does.
+func resolveLockDependency(packages map[string]lockPackage, from, name string) (string, bool) {
+	for loc := from; ; {
+		candidate := path.Join(loc, "node_modules", name)
+		if _, ok := packages[candidate]; ok {
+			return candidate, true
+		}
+
+		if len(loc) == 0 {
+			return "", false
+		}
+
+		// workspaces and top level packages resolve from the root next
+		if i := strings.LastIndex(loc, "/node_modules/"); i >= 0 {
+			loc = loc[:i]
+		} else {
+			loc = ""
+		}
+	}
+}
+
+// dependencyPaths walks the production dependencies from the given locations,
+// returning the shortest dependency path, e.g. app > mkdirp > minimist, to every
+// package reached.
+func dependencyPaths(packages map[string]lockPackage, starts []string) map[string][]string {
+	type step struct {
+		loc  string
+		path []string
+	}
+
+	paths := make(map[string][]string)
+
+	var queue []step
+
+	for _, s := range starts {
+		name := packages[s].Name
+		if len(name) == 0 {
+			name = s
+		}
+
+		queue = append(queue, step{loc: s, path: []string{name}})
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, name := range packages[current.loc].runtimeDependencies() {
+			loc, ok := resolveLockDependency(packages, current.loc, name)
+			if !ok {
+				continue
+			}
+
+			// workspaces are installed as links to their directory
+			if pkg := packages[loc]; pkg.Link {
+				loc = pkg.Resolved
+			}
+
+			if _, seen := paths[loc]; seen || slices.Contains(starts, loc) {
+				continue
+			}
+
+			p := append(append([]string{}, current.path...), name)
+			paths[loc] = p
+			queue = append(queue, step{loc: loc, path: p})
+		}
+	}
+
+	return paths
+}
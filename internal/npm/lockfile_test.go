@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// testPackageLock is a lockfileVersion 3 workspace project, app depends on mkdirp
+// which depends on its own minimist, lib depends on the hoisted minimist.
+const testPackageLock = `{
+  "name": "root",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "root",
+      "workspaces": ["packages/*"],
+      "dependencies": {"mkdirp": "^0.5.5"},
+      "devDependencies": {"eslint-gpl": "^1.0.0"}
+    },
+    "packages/app": {
+      "name": "app",
+      "version": "1.0.0",
+      "dependencies": {"lib": "^1.0.0", "mkdirp": "^0.5.5"}
+    },
+    "packages/lib": {
+      "name": "lib",
+      "version": "1.0.0",
+      "dependencies": {"minimist": "^1.2.0"}
+    },
+    "node_modules/app": {"resolved": "packages/app", "link": true},
+    "node_modules/lib": {"resolved": "packages/lib", "link": true},
+    "node_modules/mkdirp": {
+      "version": "0.5.5",
+      "resolved": "https://registry.npmjs.org/mkdirp/-/mkdirp-0.5.5.tgz",
+      "integrity": "sha512-mkdirp",
+      "license": "MIT",
+      "dependencies": {"minimist": "^0.2.0"}
+    },
+    "node_modules/mkdirp/node_modules/minimist": {
+      "version": "0.2.4",
+      "resolved": "https://registry.npmjs.org/minimist/-/minimist-0.2.4.tgz",
+      "integrity": "sha512-minimist-old",
+      "license": "GPL-3.0-only"
+    },
+    "node_modules/minimist": {
+      "version": "1.2.8",
+      "resolved": "https://registry.npmjs.org/minimist/-/minimist-1.2.8.tgz",
+      "integrity": "sha512-minimist",
+      "license": "MIT"
+    },
+    "node_modules/eslint-gpl": {
+      "version": "1.0.0",
+      "dev": true,
+      "license": "GPL-3.0-only"
+    }
+  }
+}`
+
+func TestPlugin_readPackageLock(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+
+	lock, err := p.readPackageLock()
+	if lock != nil || err != nil {
+		t.Fatalf("readPackageLock() = %v, %v without a lockfile", lock, err)
+	}
+
+	if err := afero.WriteFile(fs, packageLockFile, []byte(`{"lockfileVersion": 1, "dependencies": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.readPackageLock(); err == nil || !strings.Contains(err.Error(), "lockfileVersion 1") {
+		t.Errorf("readPackageLock() error = %v, want lockfileVersion 1 rejected", err)
+	}
+
+	if err := afero.WriteFile(fs, packageLockFile, []byte(testPackageLock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = p.readPackageLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lock.Packages["node_modules/mkdirp/node_modules/minimist"].License; got != "GPL-3.0-only" {
+		t.Errorf("license = %q, want GPL-3.0-only", got)
+	}
+}
+
+func TestDependencyPaths(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+
+	if err := afero.WriteFile(fs, packageLockFile, []byte(testPackageLock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := p.readPackageLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		starts []string
+		want   map[string][]string
+	}{
+		{
+			name:   "root",
+			starts: []string{""},
+			want: map[string][]string{
+				"node_modules/mkdirp":                       {"root", "mkdirp"},
+				"node_modules/mkdirp/node_modules/minimist": {"root", "mkdirp", "minimist"},
+			},
+		},
+		{
+			name:   "workspace",
+			starts: []string{"packages/app"},
+			want: map[string][]string{
+				"packages/lib":                              {"app", "lib"},
+				"node_modules/mkdirp":                       {"app", "mkdirp"},
+				"node_modules/minimist":                     {"app", "lib", "minimist"},
+				"node_modules/mkdirp/node_modules/minimist": {"app", "mkdirp", "minimist"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependencyPaths(lock.Packages, tt.starts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependencyPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlugin_installedPackages(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+
+	files := map[string]string{
+		"package.json":                                     `{"name": "app", "dependencies": {"@corp/a": "^1.0.0"}}`,
+		"node_modules/@corp/a/package.json":                `{"name": "@corp/a", "version": "1.0.0", "license": {"type": "MIT"}, "dependencies": {"b": "^2.0.0"}}`,
+		"node_modules/@corp/a/node_modules/b/package.json": `{"name": "b", "version": "2.0.0", "licenses": [{"type": "MIT"}, {"type": "GPL-2.0"}]}`,
+		"node_modules/.package-lock.json":                  `{}`,
+	}
+
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	packages, err := p.installedPackages([]string{""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 3 {
+		t.Errorf("installedPackages() found %d packages, want 3: %v", len(packages), packages)
+	}
+
+	if got := packages["node_modules/@corp/a"].License; got != "MIT" {
+		t.Errorf("license = %q, want MIT", got)
+	}
+
+	if got := packages["node_modules/@corp/a/node_modules/b"].License; got != "(MIT OR GPL-2.0)" {
+		t.Errorf("license = %q, want (MIT OR GPL-2.0)", got)
+	}
+
+	paths := dependencyPaths(packages, []string{""})
+	if got := paths["node_modules/@corp/a/node_modules/b"]; !reflect.DeepEqual(got, []string{"app", "@corp/a", "b"}) {
+		t.Errorf("path = %v, want app > @corp/a > b", got)
+	}
+}
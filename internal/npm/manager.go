@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// npmManager publishes with the npm CLI.
+	npmManager = "npm"
+	// yarnManager publishes with Yarn Berry (2+).
+	yarnManager = "yarn"
+	// pnpmManager publishes with pnpm.
+	pnpmManager = "pnpm"
+)
+
+// detectPackageManager picks the package manager from the packageManager field
+// of the root package.json, or the lockfile and configuration files of the project.
+// https://nodejs.org/api/packages.html#packagemanager
+func (p *plugin) detectPackageManager() string {
+	manager := npmManager
+
+	if np, err := p.readPackage("."); err == nil && len(np.PackageManager) > 0 {
+		manager = pinnedPackageManager(np.PackageManager)
+	} else if ok, _ := p.os.Exists(pnpmLockFile); ok {
+		manager = pnpmManager
+	} else if ok, _ := p.os.Exists(yarnrcFile); ok {
+		manager = yarnManager
+	}
+
+	log.WithFields(log.Fields{
+		"manager": manager,
+	}).Info("Detected package manager")
+
+	return manager
+}
+
+// pinnedPackageManager returns the package manager of a packageManager field, e.g. yarn@4.1.0.
+func pinnedPackageManager(spec string) string {
+	name, version := splitPackageSpec(spec)
+
+	switch name {
+	case pnpmManager:
+		return pnpmManager
+	case yarnManager:
+		// Yarn Classic uses the npm registry protocol and .npmrc
+		if v, err := semver.NewVersion(version); err == nil && v.Major() >= 2 {
+			return yarnManager
+		}
+	}
+
+	return npmManager
+}
+
+// npmPublishes reports whether packages are published with npm rather than yarn or pnpm.
+func (p *plugin) npmPublishes() bool {
+	return p.manager != yarnManager && p.manager != pnpmManager
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_detectPackageManager(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkg      string
+		yarnrc   bool
+		lockfile string
+		manager  string
+	}{
+		{name: "npm", pkg: `{"name": "test", "version": "1.0.0"}`, manager: npmManager},
+		{name: "berry", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "yarn@4.1.0+sha224.abc"}`, manager: yarnManager},
+		{name: "classic", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "yarn@1.22.19"}`, manager: npmManager},
+		{name: "pinned npm", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "npm@10.2.0"}`, yarnrc: true, manager: npmManager},
+		{name: "yarnrc", pkg: `{"name": "test", "version": "1.0.0"}`, yarnrc: true, manager: yarnManager},
+		{name: "pnpm", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "pnpm@9.1.0"}`, manager: pnpmManager},
+		{name: "pnpm lockfile", pkg: `{"name": "test", "version": "1.0.0"}`, lockfile: pnpmLockFile, manager: pnpmManager},
+		{name: "npm lockfile", pkg: `{"name": "test", "version": "1.0.0"}`, lockfile: "package-lock.json", manager: npmManager},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, fs := createTestPlugin(t, &Config{})
+
+			if err := afero.WriteFile(fs, "package.json", []byte(tt.pkg), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tt.lockfile) > 0 {
+				if err := afero.WriteFile(fs, tt.lockfile, []byte("lockfileVersion: '9.0'\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.yarnrc {
+				if err := afero.WriteFile(fs, yarnrcFile, []byte("nodeLinker: node-modules\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := p.detectPackageManager(); got != tt.manager {
+				t.Errorf("detectPackageManager() = %s, want %s", got, tt.manager)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// npmPin is the npm version requested by the npm_version parameter or the packageManager field.
+type npmPin struct {
+	// Spec is the version or range installed.
+	Spec string
+	// Source names where the version was requested.
+	Source string
+	// Corepack installs the version with corepack, which reads the packageManager field itself.
+	Corepack bool
+
+	constraint *semver.Constraints
+}
+
+// pinnedNpm returns the requested npm version, the npm_version parameter takes
+// precedence over the packageManager field. No pin is returned when neither is set.
+func (p *plugin) pinnedNpm() (*npmPin, error) {
+	if len(p.config.NpmVersion) > 0 {
+		c, err := semver.NewConstraint(p.config.NpmVersion)
+		if err != nil {
+			return nil, fmt.Errorf("npm_version must be a version or semver range: %w", err)
+		}
+
+		return &npmPin{Spec: p.config.NpmVersion, Source: "npm_version", constraint: c}, nil
+	}
+
+	// commands acting on a package name may run without a package.json
+	np, err := p.readPackage(".")
+	if err != nil || len(np.PackageManager) == 0 {
+		return nil, nil
+	}
+
+	name, version := splitPackageSpec(np.PackageManager)
+	if name != npmManager {
+		return nil, nil
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("packageManager %s is not a valid npm version: %w", np.PackageManager, err)
+	}
+
+	// the integrity hash after + is only understood by corepack
+	exact, err := v.SetMetadata("")
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := semver.NewConstraint("=" + exact.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &npmPin{Spec: exact.String(), Source: "packageManager", Corepack: true, constraint: c}, nil
+}
+
+// installNpm installs the pinned npm version when the installed npm does not satisfy it.
+func (p *plugin) installNpm() error {
+	pin, err := p.pinnedNpm()
+	if err != nil || pin == nil {
+		return err
+	}
+
+	if current, ok := p.npmSatisfies(pin); ok {
+		log.WithFields(log.Fields{
+			"npm":    current,
+			"source": pin.Source,
+		}).Info("Pinned npm version already installed")
+
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"npm":    pin.Spec,
+		"source": pin.Source,
+	}).Info("Installing pinned npm version")
+
+	if pin.Corepack {
+		// corepack verifies the integrity hash of the packageManager field
+		// https://github.com/nodejs/corepack#corepack-install
+		if err = p.corepackInstall(); err != nil {
+			log.Warnf("corepack could not install npm %s, installing with npm instead: %v", pin.Spec, err)
+		}
+	}
+
+	if !pin.Corepack || err != nil {
+		// https://docs.npmjs.com/cli/commands/npm-install
+		args := []string{"install", "--global", "npm@" + pin.Spec}
+
+		if len(p.config.Registry) > 0 {
+			args = append(args, "--registry", p.config.Registry)
+		}
+
+		if _, err := p.cli.RunCommandBytes("npm", args...); err != nil {
+			return fmt.Errorf("failed to install npm %s: %w", pin.Spec, err)
+		}
+	}
+
+	current, ok := p.npmSatisfies(pin)
+	if !ok {
+		return fmt.Errorf("npm %s was requested by %s but npm %s is installed", pin.Spec, pin.Source, current)
+	}
+
+	log.WithFields(log.Fields{
+		"npm": current,
+	}).Info("Installed pinned npm version")
+
+	return nil
+}
+
+// corepackInstall lets corepack provide the npm of the packageManager field.
+func (p *plugin) corepackInstall() error {
+	if _, err := p.cli.RunCommandBytes("corepack", "enable", "npm"); err != nil {
+		return err
+	}
+
+	_, err := p.cli.RunCommandBytes("corepack", "install")
+
+	return err
+}
+
+// npmSatisfies returns the installed npm version and whether it satisfies the pin.
+func (p *plugin) npmSatisfies(pin *npmPin) (string, bool) {
+	o, err := p.cli.RunCommandString("npm", "--version")
+	if err != nil {
+		log.Debugf("unable to get the npm version: %v", err)
+
+		return "none", false
+	}
+
+	current := strings.TrimSpace(o)
+
+	v, err := semver.NewVersion(current)
+	if err != nil {
+		return current, false
+	}
+
+	return current, pin.constraint.Check(v)
+}
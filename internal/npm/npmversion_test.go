@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_pinnedNpm(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		pkg      string
+		spec     string
+		corepack bool
+	}{
+		{name: "none", pkg: `{"name": "test", "version": "1.0.0"}`},
+		{name: "other manager", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "pnpm@9.1.0"}`},
+		{name: "packageManager", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "npm@10.2.0+sha512.abc"}`, spec: "10.2.0", corepack: true},
+		{name: "parameter", version: "^10.8", pkg: `{"name": "test", "version": "1.0.0", "packageManager": "npm@10.2.0"}`, spec: "^10.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, fs := createTestPlugin(t, &Config{NpmVersion: tt.version})
+
+			if err := afero.WriteFile(fs, "package.json", []byte(tt.pkg), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			pin, err := p.pinnedNpm()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tt.spec) == 0 {
+				if pin != nil {
+					t.Errorf("pinnedNpm() = %+v, want none", pin)
+				}
+
+				return
+			}
+
+			if pin == nil || pin.Spec != tt.spec || pin.Corepack != tt.corepack {
+				t.Errorf("pinnedNpm() = %+v, want %s corepack %t", pin, tt.spec, tt.corepack)
+			}
+		})
+	}
+}
+
+func TestPlugin_installNpm_Satisfied(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{NpmVersion: "10"})
+
+	mock.EXPECT().RunCommandString("npm", "--version").Return("10.8.2\n", nil)
+
+	if err := p.installNpm(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_installNpm_Global(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{NpmVersion: "10.8.2", Registry: "https://npm.corp.test.com"})
+
+	gomock.InOrder(
+		mock.EXPECT().RunCommandString("npm", "--version").Return("9.8.1\n", nil),
+		mock.EXPECT().
+			RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"install", "--global", "npm@10.8.2", "--registry", "https://npm.corp.test.com"})).
+			Return([]byte{}, nil),
+		mock.EXPECT().RunCommandString("npm", "--version").Return("10.8.2\n", nil),
+	)
+
+	if err := p.installNpm(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_installNpm_Corepack(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{})
+
+	if err := afero.WriteFile(fs, "package.json", []byte(`{"name": "test", "version": "1.0.0", "packageManager": "npm@10.2.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gomock.InOrder(
+		mock.EXPECT().RunCommandString("npm", "--version").Return("10.8.2\n", nil),
+		mock.EXPECT().RunCommandBytes(gomock.Eq("corepack"), gomock.Eq([]string{"enable", "npm"})).Return([]byte{}, nil),
+		mock.EXPECT().RunCommandBytes(gomock.Eq("corepack"), gomock.Eq([]string{"install"})).Return([]byte{}, nil),
+		mock.EXPECT().RunCommandString("npm", "--version").Return("10.2.0\n", nil),
+	)
+
+	if err := p.installNpm(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_installNpm_Unsatisfied(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{NpmVersion: "99"})
+
+	gomock.InOrder(
+		mock.EXPECT().RunCommandString("npm", "--version").Return("10.8.2\n", nil),
+		mock.EXPECT().
+			RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"install", "--global", "npm@99"})).
+			Return([]byte("ETARGET"), errors.New("exit status 1")),
+	)
+
+	err := p.installNpm()
+	if err == nil || !strings.Contains(err.Error(), "failed to install npm 99") {
+		t.Errorf("installNpm() error = %v, want install failure", err)
+	}
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// packManifestFile is the name of the manifest written next to the tarballs.
+const packManifestFile = "pack-manifest.json"
+
+type packResponse struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Size         int64  `json:"size"`
+	UnpackedSize int64  `json:"unpackedSize"`
+	Shasum       string `json:"shasum"`
+	Integrity    string `json:"integrity"`
+	Filename     string `json:"filename"`
+	EntryCount   int    `json:"entryCount"`
+}
+
+// packResult describes a tarball in the pack manifest.
+type packResult struct {
+	Filename     string `json:"filename"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Size         int64  `json:"size"`
+	UnpackedSize int64  `json:"unpackedSize"`
+	FileCount    int    `json:"fileCount"`
+	Shasum       string `json:"shasum"`
+	Integrity    string `json:"integrity"`
+}
+
+// packPlugin creates tarballs without publishing them.
+type packPlugin struct {
+	*plugin
+}
+
+// Exec runs the pack plugin.
+func (p *packPlugin) Exec() error {
+	// packing only needs credentials for lifecycle scripts, which read them from .npmrc
+	if err := p.setup(false); err != nil {
+		return err
+	}
+
+	return p.pack()
+}
+
+// pack creates tarballs of the package or workspaces and writes a manifest describing them.
+// https://docs.npmjs.com/cli/commands/npm-pack
+func (p *plugin) pack() error {
+	dest := p.config.PackDestination
+
+	log.WithFields(log.Fields{
+		"destination": dest,
+	}).Info("Building pack command")
+
+	if err := p.os.MkdirAll(dest, 0777); err != nil {
+		return fmt.Errorf("failed to create pack destination: %w", err)
+	}
+
+	var args = []string{"pack", "--json", "--pack-destination", dest}
+
+	if p.config.DryRun {
+		log.Info("Doing a dry run")
+
+		args = append(args, "--dry-run")
+	}
+
+	if p.config.Workspaces && p.config.hasWorkspaceFilters() {
+		workspaces, err := p.checkForWorkspaces()
+		if err != nil {
+			return err
+		}
+
+		for _, w := range workspaces {
+			log.Info("Packing workspace " + w)
+
+			args = append(args, "--workspace", w)
+		}
+	} else if p.config.Workspaces {
+		log.Info("Packing all workspaces")
+
+		args = append(args, "--workspaces")
+	}
+
+	if len(p.config.Workspace) > 0 {
+		log.Info("Packing workspace " + p.config.Workspace)
+
+		args = append(args, "--workspace", p.config.Workspace)
+	}
+
+	out, err := p.cli.RunCommandBytes("npm", args...)
+	if err != nil {
+		return fmt.Errorf("pack failed: %w", err)
+	}
+
+	var res []packResponse
+	if err := json.Unmarshal(out, &res); err != nil {
+		return fmt.Errorf("failed to convert npm pack response: %w", err)
+	}
+
+	manifest := make([]packResult, 0, len(res))
+
+	for _, r := range res {
+		log.WithFields(log.Fields{
+			"name":     r.Name,
+			"version":  r.Version,
+			"filename": r.Filename,
+			"size":     r.Size,
+		}).Info("Packed node package")
+
+		manifest = append(manifest, packResult{
+			Filename:     r.Filename,
+			Name:         r.Name,
+			Version:      r.Version,
+			Size:         r.Size,
+			UnpackedSize: r.UnpackedSize,
+			FileCount:    r.EntryCount,
+			Shasum:       r.Shasum,
+			Integrity:    r.Integrity,
+		})
+	}
+
+	if p.config.DryRun {
+		log.Info("Dry run, skipping pack manifest")
+
+		return nil
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to convert pack manifest: %w", err)
+	}
+
+	fp := filepath.Join(dest, packManifestFile)
+
+	if err := p.os.WriteFile(fp, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write pack manifest: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"path":     fp,
+		"tarballs": len(manifest),
+	}).Info("Successfully wrote pack manifest")
+
+	return nil
+}
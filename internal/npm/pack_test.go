@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"path"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+const packRes = `[
+	{
+		"id": "@vela-npm/1@1.0.0",
+		"name": "@vela-npm/1",
+		"version": "1.0.0",
+		"size": 312,
+		"unpackedSize": 534,
+		"shasum": "bdd2c58c2c8f9a1a1dd0c1d8d3b8e1f2f07b1c7e",
+		"integrity": "sha512-abc",
+		"filename": "vela-npm-1-1.0.0.tgz",
+		"files": [],
+		"entryCount": 3,
+		"bundled": []
+	}
+]`
+
+func TestPlugin_pack(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		PackDestination: "dist",
+		Workspaces:      true,
+	})
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"pack", "--json", "--pack-destination", "dist", "--workspaces"})).
+		Return([]byte(packRes), nil)
+
+	err := p.pack()
+	if err != nil {
+		t.Error(err)
+	}
+
+	f, err := afero.ReadFile(fs, path.Join("dist", packManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest []packResult
+	if err := json.Unmarshal(f, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	want := packResult{
+		Filename:     "vela-npm-1-1.0.0.tgz",
+		Name:         "@vela-npm/1",
+		Version:      "1.0.0",
+		Size:         312,
+		UnpackedSize: 534,
+		FileCount:    3,
+		Shasum:       "bdd2c58c2c8f9a1a1dd0c1d8d3b8e1f2f07b1c7e",
+		Integrity:    "sha512-abc",
+	}
+
+	if len(manifest) != 1 || manifest[0] != want {
+		t.Errorf("unexpected manifest %v", manifest)
+	}
+}
+
+func TestPlugin_pack_DryRun(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		PackDestination: ".",
+		DryRun:          true,
+	})
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"pack", "--json", "--pack-destination", ".", "--dry-run"})).
+		Return([]byte(packRes), nil)
+
+	err := p.pack()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if ok, _ := afero.Exists(fs, packManifestFile); ok {
+		t.Error("pack manifest should not be written on a dry run")
+	}
+}
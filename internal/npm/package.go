@@ -2,8 +2,12 @@
 package npm
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	log "github.com/sirupsen/logrus"
@@ -12,16 +16,63 @@ import (
 type packageJSON struct {
 	Name          string        `json:"name"`
 	Version       string        `json:"version"`
+	Private       bool          `json:"private,omitempty"`
 	PublishConfig publishConfig `json:"publishConfig"`
-	Workspaces    []string      `json:"workspaces"`
+	Workspaces    workspaces    `json:"workspaces"`
+
+	// PackageManager pins the package manager of the project, e.g. yarn@4.1.0.
+	PackageManager string `json:"packageManager,omitempty"`
+
+	// License is an SPDX expression, Licenses is its deprecated array form.
+	License  license   `json:"license,omitempty"`
+	Licenses []license `json:"licenses,omitempty"`
+
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
 }
 
+// publishConfig holds the publish settings of a package, which take precedence
+// over the parameters for that package.
 type publishConfig struct {
-	Registry string `json:"registry"`
+	Registry   string `json:"registry,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Access     string `json:"access,omitempty"`
+	Provenance bool   `json:"provenance,omitempty"`
+}
+
+// workspaces is the workspaces declaration of a package.json, either an
+// array of patterns or the Yarn style object with packages and nohoist.
+type workspaces struct {
+	Packages []string `json:"packages"`
+	NoHoist  []string `json:"nohoist,omitempty"`
+}
+
+// UnmarshalJSON accepts both the array and object forms of workspaces.
+func (w *workspaces) UnmarshalJSON(b []byte) error {
+	var patterns []string
+	if err := json.Unmarshal(b, &patterns); err == nil {
+		w.Packages = patterns
+
+		return nil
+	}
+
+	// alias avoids recursing into this method
+	type alias workspaces
+
+	var obj alias
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return fmt.Errorf("workspaces must be an array or an object with packages: %w", err)
+	}
+
+	*w = workspaces(obj)
+
+	return nil
 }
 
 // Validate makes sure basic package information is present.
-func (p *packageJSON) Validate(registry string) error {
+func (p *packageJSON) Validate() error {
 	if len(p.Name) == 0 {
 		return errors.New("name not found in package.json")
 	}
@@ -34,15 +85,60 @@ func (p *packageJSON) Validate(registry string) error {
 		return fmt.Errorf("package version error: %w", err)
 	}
 
-	// make sure given registry matches what's in "publishConfig"
+	// npm refuses to publish private packages
+	// https://docs.npmjs.com/cli/configuring-npm/package-json#private
+	if p.Private {
+		return fmt.Errorf("package %s is private in package.json and cannot be published", p.Name)
+	}
+
+	// "publishConfig" settings are applied to this package instead of the parameters
 	// https://docs.npmjs.com/files/package.json#publishconfig
-	if len(p.PublishConfig.Registry) != 0 && len(registry) != 0 {
-		if p.PublishConfig.Registry != registry {
-			return fmt.Errorf("PublishConfig registry %s does not match given registry %s", p.PublishConfig.Registry, registry)
+	if len(p.PublishConfig.Registry) != 0 {
+		if _, err := url.ParseRequestURI(p.PublishConfig.Registry); err != nil {
+			return fmt.Errorf("publishConfig registry %s is not a valid URL: %w", p.PublishConfig.Registry, err)
 		}
 
-		log.Trace("Registry matches the registry parameter")
+		log.Tracef("%s publishes to registry %s", p.Name, p.PublishConfig.Registry)
+	}
+
+	if len(p.PublishConfig.Tag) != 0 {
+		if _, err := semver.NewVersion(p.PublishConfig.Tag); err == nil {
+			return errors.New("publishConfig tag should not have semantic versioning")
+		}
+	}
+
+	switch p.PublishConfig.Access {
+	case "", "public", "restricted":
+	default:
+		return fmt.Errorf("publishConfig access %s is not recognized, use 'public' or 'restricted'", p.PublishConfig.Access)
 	}
 
 	return nil
 }
+
+// splitPackageSpec splits a name@version spec into its name and version,
+// taking care of the leading @ in scoped package names.
+func splitPackageSpec(spec string) (string, string) {
+	i := strings.LastIndex(spec, "@")
+	if i <= 0 {
+		return spec, ""
+	}
+
+	return spec[:i], spec[i+1:]
+}
+
+// runtimeDependencies returns the names of every dependency that must be
+// installable alongside the package, excluding devDependencies.
+func (p *packageJSON) runtimeDependencies() []string {
+	var names []string
+
+	for _, deps := range []map[string]string{p.Dependencies, p.PeerDependencies, p.OptionalDependencies} {
+		for name := range deps {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
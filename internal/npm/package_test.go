@@ -15,7 +15,7 @@ func TestPackage_Validate_NoRegistry(t *testing.T) {
 			Registry: "",
 		},
 	}
-	err := p.Validate("")
+	err := p.Validate()
 
 	logrus.Warn(err)
 
@@ -31,7 +31,7 @@ func TestPackage_Validate_NoName(t *testing.T) {
 			Registry: "",
 		},
 	}
-	err := p.Validate("")
+	err := p.Validate()
 
 	logrus.Warn(err)
 
@@ -47,7 +47,7 @@ func TestPackage_Validate_NoVersion(t *testing.T) {
 			Registry: "",
 		},
 	}
-	err := p.Validate("")
+	err := p.Validate()
 
 	logrus.Warn(err)
 
@@ -64,7 +64,7 @@ func TestPackage_Validate_BadVersion(t *testing.T) {
 			Registry: "",
 		},
 	}
-	err := p.Validate("")
+	err := p.Validate()
 
 	logrus.Warn(err)
 
@@ -73,7 +73,7 @@ func TestPackage_Validate_BadVersion(t *testing.T) {
 	}
 }
 
-func TestPackage_Validate_RegistryMismatch(t *testing.T) {
+func TestPackage_Validate_RegistryNotURL(t *testing.T) {
 	p := &packageJSON{
 		Name:    "test-package",
 		Version: "1.0.0",
@@ -81,7 +81,7 @@ func TestPackage_Validate_RegistryMismatch(t *testing.T) {
 			Registry: "someRegistry",
 		},
 	}
-	err := p.Validate("otherRegistry")
+	err := p.Validate()
 
 	logrus.Warn(err)
 
@@ -89,3 +89,70 @@ func TestPackage_Validate_RegistryMismatch(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestPackage_Validate_RegistryOverride(t *testing.T) {
+	p := &packageJSON{
+		Name:    "test-package",
+		Version: "1.0.0",
+		PublishConfig: publishConfig{
+			Registry: "https://npm.internal.test.com/",
+			Tag:      "next",
+			Access:   "restricted",
+		},
+	}
+	err := p.Validate()
+
+	logrus.Warn(err)
+
+	if err != nil {
+		t.Fail()
+	}
+}
+
+func TestPackage_Validate_PublishConfigAccess(t *testing.T) {
+	p := &packageJSON{
+		Name:    "test-package",
+		Version: "1.0.0",
+		PublishConfig: publishConfig{
+			Access: "private",
+		},
+	}
+	err := p.Validate()
+
+	logrus.Warn(err)
+
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPackage_Validate_Private(t *testing.T) {
+	p := &packageJSON{
+		Name:    "test-package",
+		Version: "1.0.0",
+		Private: true,
+	}
+	err := p.Validate()
+
+	logrus.Warn(err)
+
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPackage_splitPackageSpec(t *testing.T) {
+	tests := map[string][2]string{
+		"vela-npm":                {"vela-npm", ""},
+		"vela-npm@1.0.0":          {"vela-npm", "1.0.0"},
+		"@go-vela/vela-npm":       {"@go-vela/vela-npm", ""},
+		"@go-vela/vela-npm@1.0.0": {"@go-vela/vela-npm", "1.0.0"},
+	}
+
+	for spec, want := range tests {
+		name, version := splitPackageSpec(spec)
+		if name != want[0] || version != want[1] {
+			t.Errorf("splitPackageSpec(%s) = %s, %s", spec, name, version)
+		}
+	}
+}
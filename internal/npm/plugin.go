@@ -2,14 +2,13 @@
 package npm
 
 import (
-	b64 "encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
@@ -23,11 +22,18 @@ type Plugin interface {
 	Exec() error
 }
 
-// implementation for Plugin.
+// implementation of the core shared by every Plugin.
 type plugin struct {
 	config *Config
 	cli    shell.OSContext
 	os     *afero.Afero
+
+	// manager is the package manager publishing the packages, npm, yarn or pnpm.
+	manager string
+	// caps are the capabilities of the installed npm, set once npm was verified.
+	caps *capabilities
+	// workspaces are the declared workspace directories, resolved once per run.
+	workspaces *[]string
 }
 
 type version struct {
@@ -36,19 +42,46 @@ type version struct {
 }
 
 type publishResponse struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Integrity string `json:"integrity"`
 }
 
 type workspacesPublishResponse map[string]publishResponse
 
-// NewPlugin creates a new plugin struct given Config.
+var (
+	// errPackageNotFound is returned when the registry has no record of a package.
+	errPackageNotFound = errors.New("package not found in registry")
+	// errVersionExists is returned when the package version is already published.
+	errVersionExists = errors.New("already exists")
+)
+
+// NewPlugin creates the Plugin for the action given in Config.
 func NewPlugin(c *Config) Plugin {
-	return &plugin{
+	p := &plugin{
 		config: c,
 		cli:    shell.NewOSContext(),
 		os:     &afero.Afero{Fs: afero.NewOsFs()},
 	}
+
+	switch strings.ToLower(c.Action) {
+	case VerifyAction:
+		return &verifyPlugin{p}
+	case PackAction:
+		return &packPlugin{p}
+	case DistTagAction:
+		return &distTagPlugin{p}
+	case DeprecateAction:
+		return &deprecatePlugin{p}
+	case UnpublishAction:
+		return &unpublishPlugin{p}
+	case AccessAction:
+		return &accessPlugin{p}
+	case InfoAction:
+		return &infoPlugin{p}
+	default:
+		return &publishPlugin{p}
+	}
 }
 
 // Validate assures plugin is configured correctly.
@@ -65,13 +98,40 @@ func (p *plugin) Validate() error {
 		return err
 	}
 
+	// filters that select nothing are most likely a typo
+	if p.config.hasWorkspaceFilters() {
+		workspaces, err := p.declaredWorkspaces()
+		if err != nil {
+			return err
+		}
+
+		unmatched, err := p.unmatchedWorkspaceFilters(workspaces)
+		if err != nil {
+			return err
+		}
+
+		if len(unmatched) > 0 {
+			return fmt.Errorf("workspace filters match no workspaces: %s", strings.Join(unmatched, ", "))
+		}
+	}
+
 	return nil
 }
 
-// Exec runs the plugin.
-func (p *plugin) Exec() error {
-	// run through plugin steps
-	if err := p.createNpmrc(); err != nil {
+// setup configures npm, and yarn or pnpm when the project uses it, and optionally
+// checks the connection and authentication to the registry.
+func (p *plugin) setup(authenticate bool) error {
+	p.manager = p.detectPackageManager()
+
+	// yarn writes the auth entries to .yarnrc.yml instead
+	if !p.yarnAuthOnly() {
+		if err := p.createNpmrc(); err != nil {
+			return err
+		}
+	}
+
+	// the pinned npm is installed from the configured registry
+	if err := p.installNpm(); err != nil {
 		return err
 	}
 
@@ -79,58 +139,228 @@ func (p *plugin) Exec() error {
 		return err
 	}
 
-	if err := p.authenticate(); err != nil {
+	switch p.manager {
+	case yarnManager:
+		if err := p.createYarnrc(); err != nil {
+			return err
+		}
+
+		if err := p.verifyYarn(); err != nil {
+			return err
+		}
+	case pnpmManager:
+		// pnpm reads the registry auth from .npmrc
+		if err := p.verifyPnpm(); err != nil {
+			return err
+		}
+	}
+
+	if !authenticate {
+		return nil
+	}
+
+	return p.authenticate()
+}
+
+// publishPlugin validates and publishes the package, workspaces or tarballs.
+type publishPlugin struct {
+	*plugin
+}
+
+// Exec runs the publish plugin.
+func (p *publishPlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	return p.release()
+}
+
+// release validates and publishes the package, workspaces or tarballs.
+func (p *plugin) release() (err error) {
+	results, err := p.verifyRelease()
+	if err != nil {
+		return err
+	}
+
+	if len(p.config.Tarball) > 0 {
+		return p.publishTarballs()
+	}
+
+	// npm does not understand workspace: ranges, the originals are put back even if publish fails
+	restore, err := p.rewriteWorkspaceProtocol(results)
+
+	defer func() {
+		err = errors.Join(err, restore())
+	}()
+
+	if err != nil {
 		return err
 	}
+
+	// workspaces are published one at a time in dependency order
+	return p.publishSelected(results)
+}
+
+// verifyRelease runs every pre-publish check for the package, workspaces or tarballs.
+func (p *plugin) verifyRelease() ([]packageResult, error) {
+	var results []packageResult
+
+	if len(p.config.Tarball) > 0 {
+		if err := p.verifyTarballs(); err != nil {
+			return nil, err
+		}
+	} else {
+		r, err := p.verifyPackages()
+		if err != nil {
+			return nil, err
+		}
+
+		results = r
+	}
+
+	if err := p.checkLockfile(); err != nil {
+		return nil, err
+	}
+
+	// the license check reports its violations even when the audit fails
+	workspaces := auditWorkspaces(results)
+
+	return results, errors.Join(p.audit(workspaces), p.checkLicenses(workspaces))
+}
+
+// verifyPackages validates the package or workspaces and their versions against the registry.
+func (p *plugin) verifyPackages() ([]packageResult, error) {
 	// check for workspaces in root package.json
 	workspaces, err := p.checkForWorkspaces()
 	if err != nil {
-		log.Debug("Failed to get workspaces %w", err)
+		return nil, err
 	}
+
+	var dirs []string
+
 	// if not working with workspaces, use root
 	if !p.config.Workspaces && len(p.config.Workspace) == 0 {
 		// using workspaces but none specified
 		if len(workspaces) > 0 {
-			return errors.New("using workspaces but none are specified")
+			return nil, errors.New("using workspaces but none are specified")
 		}
 
-		np, err := p.verifyPackage(".")
+		dirs = []string{"."}
+	} else if len(workspaces) == 0 {
+		// filters and private workspaces may leave nothing to publish, but no workspaces at all is a misconfiguration
+		declared, err := p.declaredWorkspaces()
 		if err != nil {
-			return fmt.Errorf("failed to verify package.json: %w", err)
+			return nil, err
 		}
 
-		if err := p.validatePackageVersion(np); err != nil {
-			return err
+		if len(declared) == 0 {
+			return nil, errors.New("using workspaces but none are declared")
 		}
-	}
+	} else {
+		dirs = workspaces
 
-	if len(workspaces) > 0 {
 		// if specific workspace is given, filter only that one
 		if len(p.config.Workspace) > 0 {
-			workspaces = []string{p.config.Workspace}
-		}
-
-		for _, w := range workspaces {
-			np, err := p.verifyPackage(w)
+			dirs = []string{p.config.Workspace}
+		} else if p.changedOnly() {
+			dirs, err = p.changedWorkspaces(dirs)
 			if err != nil {
-				return fmt.Errorf("failed to verify package.json: %w", err)
+				return nil, err
 			}
+		}
+	}
 
-			if err := p.validatePackageVersion(np); err != nil {
-				return err
-			}
+	results := make([]packageResult, len(dirs))
+
+	// every package spawns an npm view, so the lookups run concurrently
+	p.forEachParallel(len(dirs), func(i int) {
+		results[i] = p.checkPackage(dirs[i])
+	})
+
+	// results are logged in order once every lookup is done to keep the log deterministic
+	var errs []error
+
+	for i, r := range results {
+		p.logVersionCheck(r)
+
+		if r.Status == statusPending {
+			continue
+		}
+
+		// without skip_existing every problem is reported and fails the release
+		if !p.config.SkipExisting {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Dir, r.Err))
+
+			continue
+		}
+
+		if r.Status == statusSkipped {
+			results[i].Err = nil
 		}
 	}
 
-	if err := p.audit(); err != nil {
-		return err
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
-	if err := p.publish(); err != nil {
-		return err
+	return results, nil
+}
+
+// checkPackage verifies the package.json in the directory and checks its version against the registry.
+func (p *plugin) checkPackage(dir string) packageResult {
+	result := packageResult{Dir: dir, Status: statusPending}
+
+	np, err := p.verifyPackage(dir)
+	if err != nil {
+		result.Status, result.Err = statusFailed, fmt.Errorf("failed to verify package.json: %w", err)
+
+		return result
 	}
 
-	return nil
+	result.Name, result.Version, result.Package = np.Name, np.Version, np
+
+	result.FirstPublish, err = p.checkPackageVersion(np)
+	if err != nil {
+		result.Status, result.Err = statusFailed, err
+
+		if errors.Is(err, errVersionExists) {
+			result.Status = statusSkipped
+		}
+	}
+
+	return result
+}
+
+// forEachParallel calls fn for every index, running at most parallelism calls at once.
+func (p *plugin) forEachParallel(n int, fn func(i int)) {
+	limit := max(p.config.Parallelism, 1)
+
+	log.WithFields(log.Fields{
+		"packages":    n,
+		"parallelism": limit,
+	}).Debug("Checking packages concurrently")
+
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, limit)
+
+	for i := range n {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			fn(i)
+		}()
+	}
+
+	wg.Wait()
 }
 
 // VerifyNpm makes sure npm command exists.
@@ -143,10 +373,8 @@ func (p *plugin) verifyNpm() error {
 		return fmt.Errorf("NPM version command failed: %w", err)
 	}
 
-	// convert to JSON to display npm version
-	var versions version
-
-	err = json.Unmarshal(o, &versions)
+	// parse the npm and node versions to decide which flags can be used
+	versions, err := parseNpmVersion(o)
 	if err != nil {
 		return fmt.Errorf("failed to convert npm version response to JSON: %w", err)
 	}
@@ -156,36 +384,125 @@ func (p *plugin) verifyNpm() error {
 		"node": versions.Node,
 	}).Info("Verifying npm command")
 
-	return nil
+	caps := newCapabilities(versions.NPM)
+	p.caps = &caps
+
+	return p.checkCapabilities()
 }
 
+// checkForWorkspaces resolves the publishable workspace directories declared in
+// the root package.json, applying workspace_include and workspace_exclude.
 func (p *plugin) checkForWorkspaces() ([]string, error) {
-	log.Trace("Checking for workspaces...")
+	workspaces, err := p.declaredWorkspaces()
+	if err != nil {
+		return nil, err
+	}
 
-	nodePackage := packageJSON{}
+	workspaces, err = p.skipPrivateWorkspaces(workspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.filterWorkspaces(workspaces)
+}
+
+// declaredWorkspaces returns the workspace directories declared in the root
+// package.json or pnpm-workspace.yaml, resolving them on the first call.
+func (p *plugin) declaredWorkspaces() ([]string, error) {
+	if p.workspaces == nil {
+		workspaces, err := p.resolveDeclaredWorkspaces()
+		if err != nil {
+			return nil, err
+		}
+
+		p.workspaces = &workspaces
+	}
 
-	f, err := p.os.ReadFile("package.json")
+	return *p.workspaces, nil
+}
+
+// resolveDeclaredWorkspaces resolves the workspace patterns of the root package.json or pnpm-workspace.yaml.
+func (p *plugin) resolveDeclaredWorkspaces() ([]string, error) {
+	log.Trace("Checking for workspaces...")
+
+	nodePackage, err := p.readPackage(".")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read package.json %w", err)
+		return nil, err
 	}
 
-	if err := json.Unmarshal(f, &nodePackage); err != nil {
-		return nil, fmt.Errorf("failed to marshall package.json: %w", err)
+	patterns := nodePackage.Workspaces.Packages
+
+	// pnpm declares workspaces in its own file instead
+	if len(patterns) == 0 {
+		patterns, err = p.pnpmWorkspacePatterns()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if len(nodePackage.Workspaces) > 0 {
-		log.Trace(nodePackage.Workspaces)
+	if len(patterns) == 0 {
+		log.Trace("no workspaces found")
 
-		return nodePackage.Workspaces, nil
+		return nil, nil
 	}
 
-	return nil, errors.New("no workspaces found")
+	log.Trace(patterns)
+
+	workspaces, err := p.resolveWorkspaces(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"workspaces": workspaces,
+	}).Info("Resolved workspace directories")
+
+	return workspaces, nil
 }
 
-// verifyPackage makes sure the current package version is not already in the registry.
-func (p *plugin) verifyPackage(prefix string) (packageJSON, error) {
-	log.Trace("Verifying node package...")
+// targetPackages resolves the names of the packages an action applies to.
+func (p *plugin) targetPackages() ([]string, error) {
+	if len(p.config.Package) > 0 {
+		return []string{p.config.Package}, nil
+	}
+
+	dirs := []string{"."}
+
+	if len(p.config.Workspace) > 0 {
+		dirs = []string{p.config.Workspace}
+	} else if p.config.Workspaces {
+		workspaces, err := p.checkForWorkspaces()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(workspaces) == 0 {
+			return nil, errors.New("no workspaces found")
+		}
 
+		dirs = workspaces
+	}
+
+	names := make([]string, 0, len(dirs))
+
+	for _, d := range dirs {
+		np, err := p.readPackage(d)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(np.Name) == 0 {
+			return nil, fmt.Errorf("name not found in %s/package.json", d)
+		}
+
+		names = append(names, np.Name)
+	}
+
+	return names, nil
+}
+
+// readPackage reads the package.json found in the given directory.
+func (p *plugin) readPackage(prefix string) (packageJSON, error) {
 	nodePackage := packageJSON{}
 
 	if !strings.HasSuffix(prefix, "/") {
@@ -201,10 +518,28 @@ func (p *plugin) verifyPackage(prefix string) (packageJSON, error) {
 		return nodePackage, fmt.Errorf("failed to marshall package.json: %w", err)
 	}
 
-	if err := nodePackage.Validate(p.config.Registry); err != nil {
+	return nodePackage, nil
+}
+
+// verifyPackage makes sure the current package version is not already in the registry.
+func (p *plugin) verifyPackage(prefix string) (packageJSON, error) {
+	log.Trace("Verifying node package...")
+
+	nodePackage, err := p.readPackage(prefix)
+	if err != nil {
+		return nodePackage, err
+	}
+
+	if err := nodePackage.Validate(); err != nil {
 		return nodePackage, err
 	}
 
+	if p.npmPublishes() {
+		if err := p.checkProvenance(nodePackage); err != nil {
+			return nodePackage, err
+		}
+	}
+
 	log.Trace("... node package verified")
 
 	return nodePackage, nil
@@ -269,49 +604,22 @@ func (p *plugin) createNpmrc() error {
 
 	log.Debug("update-notifier successfully written")
 
-	registry, err := url.Parse(p.config.Registry)
+	// write auth config for the registry parameter and every publishConfig registry
+	auths, err := p.npmrcAuth()
 	if err != nil {
-		return fmt.Errorf("failed to parse registry URL: %w", err)
-	}
-
-	registry.Scheme = "" // Reset the scheme to empty. This makes it so we will get a protocol relative URL.
-
-	registryString := registry.String()
-	if len(registryString) > 0 {
-		if !strings.HasSuffix(registryString, "/") {
-			registryString = registryString + "/"
-		}
-
-		registryString = registryString + ":"
-
-		log.WithFields(log.Fields{
-			"registry": registryString,
-		}).Trace("auth prefix registry string")
+		return err
 	}
 
-	// write auth config
-	if len(p.config.Token) != 0 {
-		// use token
-		auth := fmt.Sprintf("%s_authToken=\"%s\"", registryString, p.config.Token)
-
+	for _, auth := range auths {
 		if _, err = f.WriteString(auth + "\n"); err != nil {
-			return fmt.Errorf("failed to write _authToken: %w", err)
+			return fmt.Errorf("failed to write auth: %w", err)
 		}
-
-		log.Debug("_authToken successfully written")
-	} else {
-		// user username/password
-		auth64 := b64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", p.config.UserName, p.config.Password)))
-
-		auth := fmt.Sprintf("%s_auth=%s", registryString, auth64)
-
-		if _, err = f.WriteString(auth + "\n"); err != nil {
-			return fmt.Errorf("failed to write _auth: %w", err)
-		}
-
-		log.Debug("_auth successfully written")
 	}
 
+	log.WithFields(log.Fields{
+		"registries": len(auths),
+	}).Debug("auth successfully written")
+
 	// write registry config if it exists
 	if len(p.config.Registry) != 0 {
 		if _, err = f.WriteString("registry=" + p.config.Registry + "\n"); err != nil {
@@ -372,6 +680,24 @@ func (p *plugin) createNpmrc() error {
 // authenticate attempts to communicate with npm.
 func (p *plugin) authenticate() error {
 	log.Info("Checking connection and authentication")
+
+	if p.manager == yarnManager {
+		// yarn reads its own auth config, there is no yarn equivalent of npm ping
+		// https://yarnpkg.com/cli/npm/whoami
+		if _, err := p.cli.RunCommandString("yarn", "npm", "whoami"); err != nil {
+			return errors.New("yarn authentication failed")
+		}
+
+		// scoped packages publish to the npmPublishRegistry of their scope
+		for _, scope := range sortedKeys(p.publishScopes()) {
+			if _, err := p.cli.RunCommandString("yarn", "npm", "whoami", "--scope", scope, "--publish"); err != nil {
+				return fmt.Errorf("yarn authentication failed for scope @%s", scope)
+			}
+		}
+
+		return nil
+	}
+
 	// make sure auth config was written successfully
 	// https://docs.npmjs.com/cli/whoami.html
 	_, err := p.cli.RunCommandString("npm", "whoami", "--registry", p.config.Registry)
@@ -380,6 +706,13 @@ func (p *plugin) authenticate() error {
 		return fmt.Errorf("npm authentication failed")
 	}
 
+	// packages with a publishConfig registry authenticate with its own token
+	for _, r := range p.publishRegistries() {
+		if _, err := p.cli.RunCommandString("npm", "whoami", "--registry", r); err != nil {
+			return fmt.Errorf("npm authentication failed for registry %s", r)
+		}
+	}
+
 	// running npm ping will verify authentication
 	// https://docs.npmjs.com/cli/ping.html
 	// this can be skipped because not all registries support this
@@ -403,33 +736,72 @@ func (p *plugin) authenticate() error {
 
 // validatePackageVersion checks package version against the registry, errors if current version is already there.
 func (p *plugin) validatePackageVersion(nodePackage packageJSON) error {
+	firstPublish, err := p.checkPackageVersion(nodePackage)
+
+	p.logVersionCheck(packageResult{
+		Name:         nodePackage.Name,
+		Version:      nodePackage.Version,
+		Package:      nodePackage,
+		FirstPublish: firstPublish,
+	})
+
+	return err
+}
+
+// checkPackageVersion errors if the current version is already in the registry,
+// reporting whether the package has never been published.
+func (p *plugin) checkPackageVersion(nodePackage packageJSON) (bool, error) {
 	// we cannot publish a version if it already exists in the registry
 	// https://docs.npmjs.com/cli-commands/view.html
-	log.WithFields(log.Fields{
-		"name":    nodePackage.Name,
-		"version": nodePackage.Version,
-	}).Info("Checking registry for the current version")
+	versions, err := p.packageVersions(nodePackage.Name, nodePackage.PublishConfig.registry(p.config.Registry))
+	if err != nil {
+		// E404 -> valid registry but package doesn't exist yet... so it's ours to take!
+		if errors.Is(err, errPackageNotFound) {
+			return true, nil
+		}
 
-	out, cmdErr := p.cli.RunCommandBytes("npm", "view", nodePackage.Name, "versions", "--registry", p.config.Registry)
-	// There was an error getting versions but doesn't mean we can't run
-	if cmdErr != nil {
-		log.Trace(fmt.Errorf("versions command failed: %w", cmdErr))
+		return false, err
+	}
 
-		var errResp shell.NPMErrorResponse
-		if err := json.Unmarshal(out, &errResp); err != nil {
-			return fmt.Errorf("failed to convert npm error response: %w", err)
+	for _, v := range versions {
+		if v == nodePackage.Version {
+			return false, fmt.Errorf("package of version %s %w", nodePackage.Version, errVersionExists)
 		}
+	}
 
-		if errResp.ErrorBlock.Code == "ENOTFOUND" { // ENOTFOUND -> not a valid registry
-			return errors.New(errResp.ErrorBlock.Summary)
-		} else if errResp.ErrorBlock.Code == "E404" { // E404 -> valid registry but package doesn't exist yet... so it's ours to take!
-			// Notify that we are publishing with a novel package name
-			log.Info("Package does not already exist in the registry, publish will claim `" + nodePackage.Name + "`")
+	log.Tracef("Version %s of %s does not already exists in registry", nodePackage.Version, nodePackage.Name)
 
-			return nil
-		}
-		// Unknown error response code
-		return errors.New(errResp.ErrorBlock.Summary)
+	return false, nil
+}
+
+// logVersionCheck logs the registry check of a package.
+func (p *plugin) logVersionCheck(r packageResult) {
+	if len(r.Name) == 0 {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"name":     r.Name,
+		"version":  r.Version,
+		"registry": r.Package.PublishConfig.registry(p.config.Registry),
+	}).Info("Checked registry for the current version")
+
+	if r.FirstPublish {
+		// Notify that we are publishing with a novel package name
+		log.Info("Package does not already exist in the registry, publish will claim `" + r.Name + "`")
+	}
+}
+
+// packageVersions fetches all published versions of a package from the registry.
+func (p *plugin) packageVersions(name, registry string) ([]string, error) {
+	if p.manager == yarnManager {
+		return p.yarnPackageVersions(name)
+	}
+
+	out, cmdErr := p.cli.RunCommandBytes("npm", "view", name, "versions", "--registry", registry)
+	// There was an error getting versions but doesn't mean we can't run
+	if cmdErr != nil {
+		return nil, npmViewError(out, cmdErr)
 	}
 
 	var versions []string
@@ -444,60 +816,37 @@ func (p *plugin) validatePackageVersion(nodePackage packageJSON) error {
 		versions = append(versions, versionString)
 	}
 
-	log.Debug("Versions found:")
-	log.Debug(versions)
-
-	for _, v := range versions {
-		if v == nodePackage.Version {
-			return errors.New("Package of version " + nodePackage.Version + " already exists")
-		}
-	}
-
-	log.Trace("Version does not already exists in registry")
+	log.WithFields(log.Fields{
+		"name": name,
+	}).Debugf("Versions found: %v", versions)
 
-	return nil
+	return versions, nil
 }
 
-func (p *plugin) audit() error {
-	if p.config.AuditLevel == None {
-		log.Warn("Audit level set to NONE, skipping audit check")
+// npmViewError converts the error response of an npm view command,
+// errPackageNotFound is returned when the package does not exist.
+func npmViewError(out []byte, cmdErr error) error {
+	log.Trace(fmt.Errorf("view command failed: %w", cmdErr))
 
-		return nil
+	var errResp shell.NPMErrorResponse
+	if err := json.Unmarshal(out, &errResp); err != nil {
+		return fmt.Errorf("failed to convert npm error response: %w", err)
 	}
 
-	// Running audit will error if given audit-level or higher is found
-	// https://docs.npmjs.com/cli/v6/commands/npm-audit
-	log.Info("Running audit check")
-
-	out, cmdErr := p.cli.RunCommandBytes("npm", "audit", "--production", "--audit-level="+p.config.AuditLevel)
-	if cmdErr != nil {
-		log.Trace(fmt.Errorf("audit command failed: %w", cmdErr))
-
-		var errResp shell.NPMErrorResponse
-		if err := json.Unmarshal(out, &errResp); (err == nil && errResp != shell.NPMErrorResponse{}) {
-			if errResp.ErrorBlock.Code == "ENOLOCK" { // ENOLOCK -> requires lockfile
-				return errors.New(errResp.ErrorBlock.Summary + " " + errResp.ErrorBlock.Detail)
-			} else if errResp.ErrorBlock.Code == "ENOAUDIT" { // ENOAUDIT -> valid registry but it doesn't support audits
-				log.Warn(errResp.ErrorBlock.Summary + " Try adding a .npmrc to your project directory or set `audit-level: none`.")
-			} else { // Unknown error response code
-				return errors.New(errResp.ErrorBlock.Summary + " " + errResp.ErrorBlock.Detail)
-			}
-		}
-	}
-
-	if cmdErr != nil {
-		return fmt.Errorf("audit failed for audit-level=%[1]s, run `npm audit --production --audit-level=%[1]s` to view vulnerabilities that need fixed", p.config.AuditLevel)
+	if errResp.ErrorBlock.Code == "ENOTFOUND" { // ENOTFOUND -> not a valid registry
+		return errors.New(errResp.ErrorBlock.Summary)
+	} else if errResp.ErrorBlock.Code == "E404" { // E404 -> valid registry but package doesn't exist
+		return errPackageNotFound
 	}
-
-	return nil
+	// Unknown error response code
+	return errors.New(errResp.ErrorBlock.Summary)
 }
 
-// publish runs the npm publish command.
-// https://docs.npmjs.com/cli/publish
-func (p *plugin) publish() error {
-	log.Info("Building publish command")
-
-	var args = []string{"publish", "--quiet"}
+// publishOptions builds the publish flags shared by every kind of publish,
+// the package's publishConfig takes precedence over the parameters.
+// https://docs.npmjs.com/cli/configuring-npm/package-json#publishconfig
+func (p *plugin) publishOptions(pc publishConfig) []string {
+	var args []string
 
 	// to see if publish would be successful but not actually publish we can do a dry run
 	if p.config.DryRun {
@@ -506,59 +855,41 @@ func (p *plugin) publish() error {
 		args = append(args, "--dry-run")
 	}
 
-	if len(p.config.Tag) != 0 {
-		log.WithFields(log.Fields{"tag": p.config.Tag}).Info("Tagging package")
-
-		args = append(args, "--tag", p.config.Tag)
-	}
-
-	if len(p.config.Access) != 0 {
-		log.WithFields(log.Fields{"access": p.config.Access}).Info("Setting package access")
+	if tag := p.publishTag(pc); len(tag) != 0 {
+		log.WithFields(log.Fields{"tag": tag}).Info("Tagging package")
 
-		args = append(args, "--access", p.config.Access)
+		args = append(args, "--tag", tag)
 	}
 
-	if p.config.Workspaces {
-		log.Info("Publishing all workspaces")
+	if access := p.publishAccess(pc); len(access) != 0 {
+		log.WithFields(log.Fields{"access": access}).Info("Setting package access")
 
-		args = append(args, "--workspaces")
+		args = append(args, "--access", access)
 	}
 
-	if len(p.config.Workspace) > 0 {
-		log.Info("Publishing workspace " + p.config.Workspace)
+	if pc.Provenance {
+		log.Info("Publishing with provenance")
 
-		args = append(args, "--workspace", p.config.Workspace)
+		args = append(args, "--provenance")
 	}
 
-	args = append(args, "--registry", p.config.Registry)
-
-	out, err := p.cli.RunCommandBytes("npm", args...)
+	return args
+}
 
-	if err != nil {
-		return fmt.Errorf("publish failed: %w", err)
+// publishTag returns the dist-tag a package is published with.
+func (p *plugin) publishTag(pc publishConfig) string {
+	if len(pc.Tag) != 0 {
+		return pc.Tag
 	}
 
-	logFields := make(log.Fields)
+	return p.config.Tag
+}
 
-	if p.config.Workspaces || len(p.config.Workspace) > 0 {
-		var res workspacesPublishResponse
-		if err := json.Unmarshal(out, &res); err != nil {
-			log.Trace("Failed to convert npm publish response")
-		} else {
-			for w := range res {
-				logFields[res[w].Name] = res[w].Version
-			}
-		}
-	} else {
-		var res publishResponse
-		if err := json.Unmarshal(out, &res); err != nil {
-			log.Trace("Failed to convert npm publish response")
-		} else {
-			logFields[res.Name] = res.Version
-		}
+// publishAccess returns the access a package is published with.
+func (p *plugin) publishAccess(pc publishConfig) string {
+	if len(pc.Access) != 0 {
+		return pc.Access
 	}
 
-	log.WithFields(logFields).Info("Successfully published node package!")
-
-	return nil
+	return p.config.Access
 }
@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	"github.com/spf13/afero"
@@ -29,6 +31,27 @@ func createTestPlugin(t *testing.T, c *Config) (*plugin, *test.MockOSContext, af
 	return &plugin{config: c, cli: m, os: a}, m, a.Fs
 }
 
+func TestPlugin_NewPlugin_Action(t *testing.T) {
+	tests := map[string]Plugin{
+		"":              &publishPlugin{},
+		PublishAction:   &publishPlugin{},
+		VerifyAction:    &verifyPlugin{},
+		PackAction:      &packPlugin{},
+		DistTagAction:   &distTagPlugin{},
+		DeprecateAction: &deprecatePlugin{},
+		UnpublishAction: &unpublishPlugin{},
+		AccessAction:    &accessPlugin{},
+		InfoAction:      &infoPlugin{},
+	}
+
+	for action, want := range tests {
+		got := NewPlugin(&Config{Action: action})
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want) {
+			t.Errorf("NewPlugin(%s) = %T, want %T", action, got, want)
+		}
+	}
+}
+
 func TestMain_ValidateNPMCommand_Success(t *testing.T) {
 	p, mock, _ := createTestPlugin(t, &Config{})
 
@@ -348,6 +371,37 @@ func TestPlugin_createNpmrc_All(t *testing.T) {
 	}
 }
 
+func TestPlugin_createNpmrc_NoCredentials(t *testing.T) {
+	c := &Config{
+		Registry: "http://registry.test.com",
+	}
+	p, mock, fs := createTestPlugin(t, c)
+	home := path.Join("usr", "mctestface")
+	fs.MkdirAll(home, 0755) //nolint:errcheck // testing
+	mock.
+		EXPECT().
+		GetHomeDir().
+		Return(home, nil)
+	mock.EXPECT().RunCommand("npm", "config", "list")
+
+	err := p.createNpmrc()
+	if err != nil {
+		t.Error(err)
+	}
+
+	f, err := afero.ReadFile(fs, path.Join(home, ".npmrc"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	npmrc := string(f)
+	testNpmrc := fmt.Sprintf("%sregistry=%s\n", npmrcDefaults, c.Registry)
+
+	if npmrc != testNpmrc {
+		t.Errorf("%s != %s", npmrc, testNpmrc)
+	}
+}
+
 func TestPlugin_authenticate(t *testing.T) {
 	p, mock, _ := createTestPlugin(t, &Config{
 		Registry: "http://registry.test.com",
@@ -491,10 +545,10 @@ func TestPlugin_audit_Skipped(t *testing.T) {
 	p, mock, _ := createTestPlugin(t, c)
 	mock.
 		EXPECT().
-		RunCommandBytes("npm", "audit", "--production", "--audit-level=none").
+		RunCommandBytes("npm", "audit", "--json", "--omit=dev", "--audit-level=none").
 		Times(0)
 
-	err := p.audit()
+	err := p.audit(nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -519,16 +573,33 @@ func TestPlugin_audit_ErrorContainLevel(t *testing.T) {
 
 	mock.
 		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--production", "--audit-level=low"})).
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=low"})).
 		Return([]byte(res), fmt.Errorf("Command failed with exit code 1"))
 
-	err := p.audit()
+	err := p.audit(nil)
 
-	if err == nil || !strings.Contains(err.Error(), "npm audit --production --audit-level=low") {
+	if err == nil || !strings.Contains(err.Error(), "npm audit --omit=dev --audit-level=low") {
 		t.Error(fmt.Errorf("audit: the audit command should give feedback to user on how to diagnose error instead got: %w", err))
 	}
 }
 
+func TestPlugin_audit_Workspaces(t *testing.T) {
+	c := &Config{
+		AuditLevel: High,
+	}
+	p, mock, _ := createTestPlugin(t, c)
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=high", "--workspace", "packages/a", "--workspace", "packages/c"})).
+		Return(nil, nil)
+
+	err := p.audit([]string{"packages/a", "packages/c"})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestPlugin_audit_FailIfNoPackageLock(t *testing.T) {
 	c := &Config{
 		AuditLevel: Critical,
@@ -544,10 +615,10 @@ func TestPlugin_audit_FailIfNoPackageLock(t *testing.T) {
 
 	mock.
 		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--production", "--audit-level=critical"})).
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=critical"})).
 		Return([]byte(res), fmt.Errorf("Command failed with exit code 1"))
 
-	err := p.audit()
+	err := p.audit(nil)
 	if err == nil {
 		t.Error("audit: critical should error when there is no package lock file")
 	}
@@ -572,10 +643,10 @@ func TestPlugin_audit_CriticalTriggerCritical(t *testing.T) {
 
 	mock.
 		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--production", "--audit-level=critical"})).
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=critical"})).
 		Return([]byte(res), fmt.Errorf("Command failed with exit code 1"))
 
-	err := p.audit()
+	err := p.audit(nil)
 	if err == nil {
 		t.Error("audit: critical should error when critical is found")
 	}
@@ -600,10 +671,10 @@ func TestPlugin_audit_CriticalTriggerHigh(t *testing.T) {
 
 	mock.
 		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--production", "--audit-level=high"})).
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"audit", "--json", "--omit=dev", "--audit-level=high"})).
 		Return([]byte(res), fmt.Errorf("Command failed with exit code 1"))
 
-	err := p.audit()
+	err := p.audit(nil)
 	if err == nil {
 		t.Error("audit: high should error when critical is found")
 	}
@@ -640,9 +711,9 @@ func TestPlugin_Publish(t *testing.T) {
 		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--registry", "http://registry.test.com"})).
 		Return([]byte(res), nil)
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+	r := p.publishPackage(packageResult{Dir: ".", Name: "@go-vela/vela-npm", Version: "1.0.0"})
+	if r.Err != nil {
+		t.Error(r.Err)
 	}
 }
 
@@ -679,9 +750,9 @@ func TestPlugin_Publish_Access(t *testing.T) {
 		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--access", "public", "--registry", "http://registry.test.com"})).
 		Return([]byte(res), nil)
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+	r := p.publishPackage(packageResult{Dir: ".", Name: "@go-vela/vela-npm", Version: "1.0.0"})
+	if r.Err != nil {
+		t.Error(r.Err)
 	}
 }
 
@@ -718,9 +789,9 @@ func TestPlugin_Publish_DryRun(t *testing.T) {
 		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--dry-run", "--registry", "http://registry.test.com"})).
 		Return([]byte(res), nil)
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+	r := p.publishPackage(packageResult{Dir: ".", Name: "@go-vela/vela-npm", Version: "1.0.0"})
+	if r.Err != nil || r.Status != statusDryRun {
+		t.Errorf("status = %s, %v", r.Status, r.Err)
 	}
 }
 
@@ -757,16 +828,16 @@ func TestPlugin_Publish_Tag(t *testing.T) {
 		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--tag", "beta", "--registry", "http://registry.test.com"})).
 		Return([]byte(res), nil)
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+	r := p.publishPackage(packageResult{Dir: ".", Name: "@go-vela/vela-npm", Version: "1.0.0"})
+	if r.Err != nil {
+		t.Error(r.Err)
 	}
 }
 
-func TestPlugin_Publish_Workspaces(t *testing.T) {
+func TestPlugin_Publish_Workspace(t *testing.T) {
 	c := &Config{
-		Workspaces: true,
-		Registry:   "http://registry.test.com",
+		Workspace: "example",
+		Registry:  "http://registry.test.com",
 	}
 	p, mock, _ := createTestPlugin(t, c)
 	res := `{
@@ -791,81 +862,57 @@ func TestPlugin_Publish_Workspaces(t *testing.T) {
 					"mode": 420
 				}
 			]
-		},
-		"@vela-npm/2": {
-			"id": "@vela-npm/2@1.0.0",
-			"name": "@vela-npm/2",
-			"version": "1.0.0",
-			"files": [
-				{
-					"path": "README.md",
-					"size": 68,
-					"mode": 420
-				},
-				{
-					"path": "index.js",
-					"size": 80,
-					"mode": 420
-				},
-				{
-					"path": "package.json",
-					"size": 386,
-					"mode": 420
-				}
-			]
 		}
 	}`
 
 	mock.
 		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspaces", "--registry", "http://registry.test.com"})).
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "example", "--registry", "http://registry.test.com"})).
 		Return([]byte(res), nil)
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+	r := p.publishPackage(packageResult{Dir: "example", Name: "@vela-npm/1", Version: "1.0.0"})
+	if r.Err != nil {
+		t.Error(r.Err)
 	}
 }
 
-func TestPlugin_Publish_Workspace(t *testing.T) {
+func TestPlugin_Publish_Workspaces(t *testing.T) {
 	c := &Config{
-		Workspace: "example",
-		Registry:  "http://registry.test.com",
+		Workspaces: true,
+		Registry:   "http://registry.test.com",
 	}
 	p, mock, _ := createTestPlugin(t, c)
-	res := `{
-		"@vela-npm/1": {
-			"id": "@vela-npm/1@1.0.0",
-			"name": "@vela-npm/1",
-			"version": "1.0.0",
-			"files": [
-				{
-					"path": "README.md",
-					"size": 68,
-					"mode": 420
-				},
-				{
-					"path": "index.js",
-					"size": 80,
-					"mode": 420
-				},
-				{
-					"path": "package.json",
-					"size": 386,
-					"mode": 420
-				}
-			]
-		}
-	}`
 
-	mock.
-		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "example", "--registry", "http://registry.test.com"})).
-		Return([]byte(res), nil)
+	// workspaces are published one at a time so each gets its own result
+	for _, w := range []string{"1", "2"} {
+		res := `{
+			"@vela-npm/` + w + `": {
+				"id": "@vela-npm/` + w + `@1.0.0",
+				"name": "@vela-npm/` + w + `",
+				"version": "1.0.0",
+				"integrity": "sha512-` + w + `"
+			}
+		}`
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+		mock.
+			EXPECT().
+			RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "packages/" + w, "--registry", "http://registry.test.com"})).
+			Return([]byte(res), nil)
+	}
+
+	results := []packageResult{
+		{Dir: "packages/1", Name: "@vela-npm/1", Version: "1.0.0", Status: statusPending},
+		{Dir: "packages/2", Name: "@vela-npm/2", Version: "1.0.0", Status: statusPending},
+	}
+
+	if err := p.publishSelected(results); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range results {
+		if r.Status != statusPublished || r.Integrity != "sha512-"+strings.TrimPrefix(r.Name, "@vela-npm/") {
+			t.Errorf("%s status = %s, integrity = %s", r, r.Status, r.Integrity)
+		}
 	}
 }
 
@@ -877,60 +924,94 @@ func TestPlugin_Publish_All(t *testing.T) {
 		Registry:   "http://registry.test.com",
 	}
 	p, mock, _ := createTestPlugin(t, c)
-	res := `{
-		"@vela-npm/1": {
-			"id": "@vela-npm/1@1.0.0",
-			"name": "@vela-npm/1",
-			"version": "1.0.0",
-			"files": [
-				{
-					"path": "README.md",
-					"size": 68,
-					"mode": 420
-				},
-				{
-					"path": "index.js",
-					"size": 80,
-					"mode": 420
-				},
-				{
-					"path": "package.json",
-					"size": 386,
-					"mode": 420
-				}
-			]
-		},
-		"@vela-npm/2": {
-			"id": "@vela-npm/2@1.0.0",
-			"name": "@vela-npm/2",
-			"version": "1.0.0",
-			"files": [
-				{
-					"path": "README.md",
-					"size": 68,
-					"mode": 420
-				},
-				{
-					"path": "index.js",
-					"size": 80,
-					"mode": 420
-				},
-				{
-					"path": "package.json",
-					"size": 386,
-					"mode": 420
-				}
-			]
+
+	for _, w := range []string{"1", "2"} {
+		mock.
+			EXPECT().
+			RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--dry-run", "--tag", "beta", "--workspace", "packages/" + w, "--registry", "http://registry.test.com"})).
+			Return([]byte(`{}`), nil)
+	}
+
+	results := []packageResult{
+		{Dir: "packages/1", Name: "@vela-npm/1", Version: "1.0.0", Status: statusPending},
+		{Dir: "packages/2", Name: "@vela-npm/2", Version: "1.0.0", Status: statusPending},
+	}
+
+	if err := p.publishSelected(results); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range results {
+		if r.Tag != "beta" || r.Status != statusDryRun {
+			t.Errorf("%s tag = %s, status = %s, want beta and %s", r, r.Tag, r.Status, statusDryRun)
 		}
-	}`
+	}
+}
+
+func TestPlugin_verifyPackages_AllConflicts(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces:  true,
+		Parallelism: 2,
+		Registry:    "http://registry.test.com",
+	})
+	writeTestWorkspaces(t, fs, `{"workspaces": ["packages/*"]}`, "packages/a", "packages/b", "packages/c")
 
 	mock.
 		EXPECT().
-		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--dry-run", "--tag", "beta", "--workspaces", "--registry", "http://registry.test.com"})).
-		Return([]byte(res), nil)
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "packages/a", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["1.0.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "packages/b", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["0.1.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "packages/c", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["0.1.0", "1.0.0"]`), nil)
 
-	err := p.publish()
-	if err != nil {
-		t.Error(err)
+	_, err := p.verifyPackages()
+	if err == nil {
+		t.Fatal("verifyPackages should have failed")
+	}
+
+	want := "packages/a: package of version 1.0.0 already exists\npackages/c: package of version 1.0.0 already exists"
+	if err.Error() != want {
+		t.Errorf("verifyPackages error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestPlugin_forEachParallel(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{
+		Parallelism: 3,
+	})
+
+	var running, peak int32
+
+	done := make([]bool, 10)
+
+	p.forEachParallel(len(done), func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		done[i] = true
+	})
+
+	for i, d := range done {
+		if !d {
+			t.Errorf("index %d was not visited", i)
+		}
+	}
+
+	if peak > 3 {
+		t.Errorf("ran %d calls at once, want at most 3", peak)
 	}
 }
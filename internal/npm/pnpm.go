@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// pnpmLockFile is the pnpm lockfile.
+	pnpmLockFile = "pnpm-lock.yaml"
+	// pnpmWorkspaceFile declares the workspaces of a pnpm project.
+	// https://pnpm.io/pnpm-workspace_yaml
+	pnpmWorkspaceFile = "pnpm-workspace.yaml"
+	// pnpmSummaryFile is written by pnpm publish --report-summary.
+	pnpmSummaryFile = "pnpm-publish-summary.json"
+)
+
+// pnpmSummary is the report of the packages published by pnpm.
+type pnpmSummary struct {
+	PublishedPackages []publishResponse `json:"publishedPackages"`
+}
+
+// verifyPnpm makes sure the pnpm command exists.
+func (p *plugin) verifyPnpm() error {
+	o, err := p.cli.RunCommandString("pnpm", "--version")
+	if err != nil {
+		return fmt.Errorf("pnpm is not available, enable it with corepack: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"pnpm": strings.TrimSpace(o),
+	}).Info("Verifying pnpm command")
+
+	return nil
+}
+
+// pnpmWorkspacePatterns reads the workspace globs from pnpm-workspace.yaml,
+// returning none when the project has no such file.
+func (p *plugin) pnpmWorkspacePatterns() ([]string, error) {
+	if ok, _ := p.os.Exists(pnpmWorkspaceFile); !ok {
+		return nil, nil
+	}
+
+	b, err := p.os.ReadFile(pnpmWorkspaceFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pnpmWorkspaceFile, err)
+	}
+
+	var ws struct {
+		Packages []string `yaml:"packages"`
+	}
+
+	if err := yaml.Unmarshal(b, &ws); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", pnpmWorkspaceFile, err)
+	}
+
+	return ws.Packages, nil
+}
+
+// publishPnpm publishes the pending packages with a single pnpm publish, which
+// orders the workspaces itself, and records the outcome from its summary report.
+// https://pnpm.io/cli/publish
+func (p *plugin) publishPnpm(results []packageResult) {
+	args := p.pnpmPublishArgs(results)
+
+	start := time.Now()
+	out, err := p.cli.RunCommandBytes("pnpm", args...)
+	duration := time.Since(start)
+
+	if err != nil {
+		log.Trace(string(out))
+	}
+
+	published, summaryErr := p.readPnpmSummary()
+	if summaryErr != nil {
+		log.Warn(summaryErr)
+	}
+
+	for i, r := range results {
+		if r.Status != statusPending {
+			continue
+		}
+
+		pc := r.Package.PublishConfig
+		r.Tag, r.Access, r.Registry = p.publishTag(pc), p.publishAccess(pc), pc.registry(p.config.Registry)
+		r.Duration = duration
+
+		res, ok := published[r.Name]
+
+		switch {
+		case ok && res.Version == r.Version:
+			r.Status, r.Integrity = p.publishedStatus(), res.Integrity
+
+			logPublished(r)
+		case err != nil:
+			r.Status, r.Err = statusFailed, fmt.Errorf("publish failed: %w", err)
+		case summaryErr != nil:
+			// without the summary there is no telling what pnpm published
+			r.Status, r.Err = statusFailed, fmt.Errorf("publish result unknown: %w", summaryErr)
+		default:
+			r.Status, r.Err = statusSkipped, errors.New("not in the pnpm publish summary")
+		}
+
+		results[i] = r
+	}
+}
+
+// pnpmPublishArgs builds the pnpm publish command, filtering to the pending workspaces.
+func (p *plugin) pnpmPublishArgs(results []packageResult) []string {
+	args := []string{"publish"}
+
+	var filters []string
+
+	for _, r := range results {
+		if r.Status == statusPending && r.Dir != "." {
+			log.Info("Publishing workspace " + r.Dir)
+
+			filters = append(filters, "--filter", r.Name)
+		}
+	}
+
+	// all workspaces are published recursively, a single workspace only needs its filter
+	// https://pnpm.io/filtering
+	if p.config.Workspaces {
+		args = append(args, "--recursive")
+	}
+
+	args = append(args, filters...)
+
+	// the tree may be on a detached HEAD in CI, the version checks already ran
+	args = append(args, "--no-git-checks", "--report-summary")
+
+	return append(args, p.publishOptions(publishConfig{})...)
+}
+
+// readPnpmSummary reads and removes the pnpm publish summary, keyed by package name.
+func (p *plugin) readPnpmSummary() (map[string]publishResponse, error) {
+	b, err := p.os.ReadFile(pnpmSummaryFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pnpmSummaryFile, err)
+	}
+
+	if err := p.os.Remove(pnpmSummaryFile); err != nil {
+		log.Debugf("failed to remove %s: %v", pnpmSummaryFile, err)
+	}
+
+	var summary pnpmSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", pnpmSummaryFile, err)
+	}
+
+	published := make(map[string]publishResponse, len(summary.PublishedPackages))
+
+	for _, res := range summary.PublishedPackages {
+		published[res.Name] = res
+	}
+
+	return published, nil
+}
+
+// pnpmAudit runs pnpm audit for the production dependencies of the whole lockfile.
+// https://pnpm.io/cli/audit
+func (p *plugin) pnpmAudit() error {
+	log.Info("Running audit check")
+
+	args := []string{"audit", "--prod", "--audit-level", p.config.AuditLevel}
+
+	if _, err := p.cli.RunCommandBytes("pnpm", args...); err != nil {
+		log.Trace(fmt.Errorf("audit command failed: %w", err))
+
+		return fmt.Errorf("audit failed for audit-level=%s, run `pnpm %s` to view vulnerabilities that need fixed",
+			p.config.AuditLevel, strings.Join(args, " "))
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func TestPlugin_declaredWorkspaces_Pnpm(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestWorkspaces(t, fs, `{"name": "root", "private": true}`, "packages/a", "packages/b", "tools/c")
+
+	workspaceFile := "packages:\n  - 'packages/*'\n  - '!packages/b'\n"
+	if err := afero.WriteFile(fs, pnpmWorkspaceFile, []byte(workspaceFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.declaredWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"packages/a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("declaredWorkspaces() = %v, want %v", got, want)
+	}
+}
+
+func TestPlugin_publishSelected_Pnpm(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{Workspaces: true, Tag: "next"})
+	p.manager = pnpmManager
+
+	results := []packageResult{
+		{Dir: "packages/a", Name: "a", Version: "1.0.0", Status: statusPending},
+		{Dir: "packages/b", Name: "b", Version: "2.0.0", Status: statusPending},
+		{Dir: "packages/c", Name: "c", Version: "1.0.0", Status: statusSkipped},
+	}
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("pnpm"), gomock.Eq([]string{
+			"publish", "--recursive", "--filter", "a", "--filter", "b", "--no-git-checks", "--report-summary", "--tag", "next",
+		})).
+		DoAndReturn(func(string, ...string) ([]byte, error) {
+			summary := `{"publishedPackages": [{"name": "a", "version": "1.0.0"}, {"name": "b", "version": "2.0.0"}]}`
+
+			return nil, afero.WriteFile(fs, pnpmSummaryFile, []byte(summary), 0644)
+		})
+
+	if err := p.publishSelected(results); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range results[:2] {
+		if r.Status != statusPublished || r.Tag != "next" {
+			t.Errorf("%s: status = %s, tag = %s", r, r.Status, r.Tag)
+		}
+	}
+
+	if results[2].Status != statusSkipped {
+		t.Errorf("skipped package was published: %s", results[2].Status)
+	}
+
+	if ok, _ := afero.Exists(fs, pnpmSummaryFile); ok {
+		t.Error("pnpm publish summary was not removed")
+	}
+}
+
+func TestPlugin_publishSelected_PnpmFailure(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{Workspace: "packages/b"})
+	p.manager = pnpmManager
+
+	results := []packageResult{
+		{Dir: "packages/b", Name: "b", Version: "2.0.0", Status: statusPending},
+	}
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("pnpm"), gomock.Eq([]string{"publish", "--filter", "b", "--no-git-checks", "--report-summary"})).
+		DoAndReturn(func(string, ...string) ([]byte, error) {
+			if err := afero.WriteFile(fs, pnpmSummaryFile, []byte(`{"publishedPackages": []}`), 0644); err != nil {
+				return nil, err
+			}
+
+			return []byte("ERR_PNPM_GIT_UNKNOWN_BRANCH"), errors.New("exit status 1")
+		})
+
+	if err := p.publishSelected(results); err == nil {
+		t.Error("publishSelected should fail when pnpm fails")
+	}
+
+	if results[0].Status != statusFailed {
+		t.Errorf("status = %s, want %s", results[0].Status, statusFailed)
+	}
+}
+
+func TestPlugin_publishSelected_PnpmMissingSummary(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{Workspace: "packages/b"})
+	p.manager = pnpmManager
+
+	results := []packageResult{
+		{Dir: "packages/b", Name: "b", Version: "2.0.0", Status: statusPending},
+	}
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("pnpm"), gomock.Eq([]string{"publish", "--filter", "b", "--no-git-checks", "--report-summary"})).
+		Return([]byte{}, nil)
+
+	if err := p.publishSelected(results); err == nil {
+		t.Error("publishSelected should fail without a pnpm publish summary")
+	}
+
+	if results[0].Status != statusFailed {
+		t.Errorf("status = %s, want %s", results[0].Status, statusFailed)
+	}
+
+	if err := afero.WriteFile(fs, pnpmSummaryFile, []byte(`not json`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results[0].Status, results[0].Err = statusPending, nil
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("pnpm"), gomock.Eq([]string{"publish", "--filter", "b", "--no-git-checks", "--report-summary"})).
+		Return([]byte{}, nil)
+
+	if err := p.publishSelected(results); err == nil || results[0].Status != statusFailed {
+		t.Errorf("publishSelected() = %v, status = %s, want an invalid summary to fail", err, results[0].Status)
+	}
+}
+
+func TestPlugin_audit_Pnpm(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{AuditLevel: "moderate"})
+	p.manager = pnpmManager
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("pnpm"), gomock.Eq([]string{"audit", "--prod", "--audit-level", "moderate"})).
+		Return([]byte{}, nil)
+
+	if err := p.audit([]string{"packages/a"}); err != nil {
+		t.Error(err)
+	}
+}
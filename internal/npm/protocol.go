@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// workspaceProtocol is the range prefix used to depend on a sibling workspace.
+// https://yarnpkg.com/features/workspaces#cross-references
+const workspaceProtocol = "workspace:"
+
+// workspaceDependency matches a "name": "workspace:range" pair, leaving the
+// rest of package.json and its formatting untouched when it is rewritten.
+var workspaceDependency = regexp.MustCompile(`"([^"]+)"(\s*:\s*)"` + workspaceProtocol + `([^"]*)"`)
+
+// rewriteWorkspaceProtocol replaces workspace protocol ranges in the packages
+// about to be published with concrete ranges of each sibling's version. The
+// returned function restores the original package.json files.
+func (p *plugin) rewriteWorkspaceProtocol(results []packageResult) (func() error, error) {
+	// originals are the package.json files before the rewrite, written back with their mode
+	type original struct {
+		content []byte
+		mode    fs.FileMode
+	}
+
+	originals := make(map[string]original)
+
+	restore := func() error {
+		var errs []error
+
+		for file, o := range originals {
+			if err := p.os.WriteFile(file, o.content, o.mode); err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", file, err))
+			}
+		}
+
+		if len(originals) > 0 && len(errs) == 0 {
+			log.Debug("Restored package.json files with workspace protocol ranges")
+		}
+
+		return errors.Join(errs...)
+	}
+
+	// yarn and pnpm replace workspace ranges themselves when packing
+	if !p.npmPublishes() {
+		return restore, nil
+	}
+
+	var versions map[string]string
+
+	for _, r := range results {
+		if r.Status != statusPending {
+			continue
+		}
+
+		file := path.Join(r.Dir, "package.json")
+
+		info, err := p.os.Stat(file)
+		if err != nil {
+			return restore, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		b, err := p.os.ReadFile(file)
+		if err != nil {
+			return restore, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		if !workspaceDependency.Match(b) {
+			continue
+		}
+
+		// sibling versions are only needed once a workspace range is found
+		if versions == nil {
+			versions, err = p.workspaceVersions()
+			if err != nil {
+				return restore, err
+			}
+		}
+
+		rewritten, err := replaceWorkspaceRanges(b, versions)
+		if err != nil {
+			return restore, fmt.Errorf("failed to rewrite workspace ranges of %s: %w", r.Name, err)
+		}
+
+		originals[file] = original{content: b, mode: info.Mode().Perm()}
+
+		if err := p.os.WriteFile(file, rewritten, info.Mode().Perm()); err != nil {
+			return restore, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+
+		log.WithFields(log.Fields{
+			"package": r.Name,
+		}).Info("Rewrote workspace protocol dependencies")
+	}
+
+	return restore, nil
+}
+
+// workspaceVersions maps the name of every declared workspace to its version.
+func (p *plugin) workspaceVersions() (map[string]string, error) {
+	dirs, err := p.declaredWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(dirs))
+
+	for _, d := range dirs {
+		np, err := p.readPackage(d)
+		if err != nil {
+			return nil, err
+		}
+
+		versions[np.Name] = np.Version
+	}
+
+	return versions, nil
+}
+
+// replaceWorkspaceRanges rewrites each workspace range in the package.json contents.
+func replaceWorkspaceRanges(b []byte, versions map[string]string) ([]byte, error) {
+	var errs []error
+
+	rewritten := workspaceDependency.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := workspaceDependency.FindSubmatch(m)
+		name, sep, spec := string(sub[1]), string(sub[2]), string(sub[3])
+
+		version, ok := versions[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("workspace %s does not exist", name))
+
+			return m
+		}
+
+		rng := workspaceRange(spec, version)
+
+		log.Debugf("%s: %s%s -> %s", name, workspaceProtocol, spec, rng)
+
+		return []byte(`"` + name + `"` + sep + `"` + rng + `"`)
+	})
+
+	return rewritten, errors.Join(errs...)
+}
+
+// workspaceRange converts a workspace protocol range to the range npm publishes,
+// following the same rules as yarn and pnpm.
+func workspaceRange(spec, version string) string {
+	switch strings.TrimSpace(spec) {
+	case "*", "":
+		return version
+	case "^":
+		return "^" + version
+	case "~":
+		return "~" + version
+	default:
+		return spec
+	}
+}
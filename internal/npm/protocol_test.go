@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+const testProtocolPackage = `{
+  "name": "@corp/ui",
+  "version": "2.0.0",
+  "dependencies": {
+    "@corp/tokens": "workspace:^",
+    "react": "^18.0.0"
+  },
+  "peerDependencies": {
+    "@corp/icons": "workspace:*"
+  },
+  "devDependencies": {
+    "@corp/tokens": "workspace:~",
+    "@corp/icons": "workspace:>=1.0.0"
+  }
+}
+`
+
+func TestPlugin_rewriteWorkspaceProtocol(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, map[string]string{
+		"packages/ui":     testProtocolPackage,
+		"packages/tokens": `{"name": "@corp/tokens", "version": "1.2.0"}`,
+		"packages/icons":  `{"name": "@corp/icons", "version": "1.0.3"}`,
+	})
+
+	results := []packageResult{
+		{Dir: "packages/ui", Name: "@corp/ui", Status: statusPending},
+		{Dir: "packages/tokens", Name: "@corp/tokens", Status: statusSkipped},
+	}
+
+	restore, err := p.rewriteWorkspaceProtocol(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "name": "@corp/ui",
+  "version": "2.0.0",
+  "dependencies": {
+    "@corp/tokens": "^1.2.0",
+    "react": "^18.0.0"
+  },
+  "peerDependencies": {
+    "@corp/icons": "1.0.3"
+  },
+  "devDependencies": {
+    "@corp/tokens": "~1.2.0",
+    "@corp/icons": ">=1.0.0"
+  }
+}
+`
+
+	b, _ := afero.ReadFile(fs, "packages/ui/package.json")
+	if string(b) != want {
+		t.Errorf("rewritten package.json = %s, want %s", b, want)
+	}
+
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ = afero.ReadFile(fs, "packages/ui/package.json")
+	if string(b) != testProtocolPackage {
+		t.Errorf("restored package.json = %s, want %s", b, testProtocolPackage)
+	}
+}
+
+func TestPlugin_rewriteWorkspaceProtocol_KeepsMode(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, map[string]string{
+		"packages/ui":     testProtocolPackage,
+		"packages/tokens": `{"name": "@corp/tokens", "version": "1.2.0"}`,
+		"packages/icons":  `{"name": "@corp/icons", "version": "1.0.3"}`,
+	})
+
+	if err := fs.Chmod("packages/ui/package.json", 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	restore, err := p.rewriteWorkspaceProtocol([]packageResult{
+		{Dir: "packages/ui", Name: "@corp/ui", Status: statusPending},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the restored file is written from scratch
+	if err := fs.Remove("packages/ui/package.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fs.Stat("packages/ui/package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("restored package.json mode = %v, want -rw-------", info.Mode().Perm())
+	}
+}
+
+func TestPlugin_rewriteWorkspaceProtocol_MissingWorkspace(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, map[string]string{
+		"packages/ui":     `{"name": "@corp/ui", "version": "2.0.0", "dependencies": {"@corp/colors": "workspace:*"}}`,
+		"packages/tokens": `{"name": "@corp/tokens", "version": "1.2.0"}`,
+		"packages/icons":  `{"name": "@corp/icons", "version": "1.0.3"}`,
+	})
+
+	restore, err := p.rewriteWorkspaceProtocol([]packageResult{
+		{Dir: "packages/ui", Name: "@corp/ui", Status: statusPending},
+	})
+	if err == nil {
+		t.Error("rewriteWorkspaceProtocol should have failed on a missing workspace")
+	}
+
+	if err := restore(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_release_RestoresWorkspaceProtocol(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces: true,
+		AuditLevel: None,
+		Registry:   "http://registry.test.com",
+	})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, map[string]string{
+		"packages/ui":     testProtocolPackage,
+		"packages/tokens": `{"name": "@corp/tokens", "version": "1.2.0"}`,
+		"packages/icons":  `{"name": "@corp/icons", "version": "1.0.3"}`,
+	})
+
+	for _, name := range []string{"@corp/icons", "@corp/tokens", "@corp/ui"} {
+		mock.
+			EXPECT().
+			RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", name, "versions", "--registry", "http://registry.test.com"})).
+			Return([]byte(`["0.1.0"]`), nil)
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "packages/icons", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "packages/tokens", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "packages/ui", "--registry", "http://registry.test.com"})).
+		Return(nil, errors.New("Process exited with status code 1"))
+
+	err := p.release()
+	if err == nil {
+		t.Error("release should have failed")
+	}
+
+	b, _ := afero.ReadFile(fs, "packages/ui/package.json")
+	if string(b) != testProtocolPackage {
+		t.Errorf("package.json was not restored after a failed publish: %s", b)
+	}
+}
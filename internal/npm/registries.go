@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	b64 "encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// parseRegistryToken parses a registry token in the form registry=token.
+func parseRegistryToken(s string) (string, string, error) {
+	registry, token, ok := strings.Cut(s, "=")
+	if !ok || len(strings.TrimSpace(registry)) == 0 || len(strings.TrimSpace(token)) == 0 {
+		return "", "", errors.New("registry_tokens entries must be in the form registry=token")
+	}
+
+	return normalizeRegistry(registry), strings.TrimSpace(token), nil
+}
+
+// normalizeRegistry trims the registry URL so the same registry always compares equal.
+func normalizeRegistry(registry string) string {
+	return strings.TrimSuffix(strings.TrimSpace(registry), "/")
+}
+
+// registry returns the registry from publishConfig, or the fallback when it is not set.
+func (c publishConfig) registry(fallback string) string {
+	if len(c.Registry) > 0 {
+		return c.Registry
+	}
+
+	return fallback
+}
+
+// publishRegistries returns the publishConfig registries of the root package
+// and its workspaces that differ from the registry parameter.
+func (p *plugin) publishRegistries() []string {
+	// commands acting on a package name may run without a package.json
+	workspaces, err := p.declaredWorkspaces()
+	if err != nil {
+		log.Debugf("not reading publishConfig registries: %v", err)
+
+		return nil
+	}
+
+	dirs := append([]string{"."}, workspaces...)
+
+	seen := map[string]bool{normalizeRegistry(p.config.Registry): true}
+
+	var registries []string
+
+	for _, d := range dirs {
+		np, err := p.readPackage(d)
+		if err != nil {
+			continue
+		}
+
+		r := normalizeRegistry(np.PublishConfig.Registry)
+		if len(r) == 0 || seen[r] {
+			continue
+		}
+
+		seen[r] = true
+
+		registries = append(registries, np.PublishConfig.Registry)
+	}
+
+	return registries
+}
+
+// registryCredential is the authentication used for a single registry.
+type registryCredential struct {
+	Registry string
+	Token    string
+	UserName string
+	Password string
+}
+
+// registryCredentials resolves the credentials of the registry parameter and
+// every other registry in use, each registry uses its own token when one is
+// given and falls back to the global credentials.
+func (p *plugin) registryCredentials() ([]registryCredential, error) {
+	tokens := make(map[string]string, len(p.config.RegistryTokens))
+
+	for _, t := range p.config.RegistryTokens {
+		registry, token, err := parseRegistryToken(t)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens[registry] = token
+	}
+
+	registries := []string{p.config.Registry}
+
+	seen := map[string]bool{normalizeRegistry(p.config.Registry): true}
+
+	for _, r := range p.publishRegistries() {
+		seen[normalizeRegistry(r)] = true
+
+		registries = append(registries, r)
+	}
+
+	// tokens for registries not used by any package are still written for lifecycle scripts
+	var extra []string
+
+	for r := range tokens {
+		if !seen[r] {
+			extra = append(extra, r)
+		}
+	}
+
+	sort.Strings(extra)
+
+	creds := make([]registryCredential, 0, len(registries)+len(extra))
+
+	for _, r := range append(registries, extra...) {
+		c := registryCredential{Registry: r, Token: tokens[normalizeRegistry(r)]}
+
+		if len(c.Token) == 0 {
+			c.Token, c.UserName, c.Password = p.config.Token, p.config.UserName, p.config.Password
+		}
+
+		creds = append(creds, c)
+	}
+
+	return creds, nil
+}
+
+// npmrcAuth returns the .npmrc auth entries for every registry in use.
+func (p *plugin) npmrcAuth() ([]string, error) {
+	creds, err := p.registryCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+
+	for _, c := range creds {
+		line, err := c.npmrcAuth()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// registryPrefix returns the protocol relative registry URL auth settings are keyed by.
+func registryPrefix(registry string) (string, error) {
+	u, err := url.Parse(registry)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse registry URL: %w", err)
+	}
+
+	u.Scheme = "" // Reset the scheme to empty. This makes it so we will get a protocol relative URL.
+
+	return u.String(), nil
+}
+
+// npmrcAuth creates the .npmrc auth entry for the registry.
+func (c registryCredential) npmrcAuth() (string, error) {
+	prefix, err := registryPrefix(c.Registry)
+	if err != nil {
+		return "", err
+	}
+
+	if len(prefix) > 0 {
+		if !strings.HasSuffix(prefix, "/") {
+			prefix = prefix + "/"
+		}
+
+		prefix = prefix + ":"
+
+		log.WithFields(log.Fields{
+			"registry": prefix,
+		}).Trace("auth prefix registry string")
+	}
+
+	switch {
+	case len(c.Token) != 0:
+		// use token
+		return fmt.Sprintf("%s_authToken=\"%s\"", prefix, c.Token), nil
+	case len(c.UserName) != 0:
+		// user username/password
+		return fmt.Sprintf("%s_auth=%s", prefix, c.basicAuth()), nil
+	default:
+		return "", nil
+	}
+}
+
+// basicAuth encodes the username and password.
+func (c registryCredential) basicAuth() string {
+	return b64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.UserName, c.Password)))
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+var testRegistryWorkspaces = map[string]string{
+	"packages/public":   `{"name": "@corp/public", "version": "1.0.0", "publishConfig": {"access": "public"}}`,
+	"packages/internal": `{"name": "@corp/internal", "version": "1.0.0", "publishConfig": {"registry": "https://npm.corp.test.com/", "tag": "next", "provenance": true}}`,
+}
+
+func TestPlugin_createNpmrc_PublishConfigRegistries(t *testing.T) {
+	c := &Config{
+		Token:          "test-token",
+		Registry:       "https://registry.npmjs.org",
+		RegistryTokens: []string{"https://npm.corp.test.com=corp-token", "https://npm.other.test.com/=other-token"},
+	}
+	p, mock, fs := createTestPlugin(t, c)
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	home := path.Join("usr", "mctestface")
+	fs.MkdirAll(home, 0755) //nolint:errcheck // testing
+	mock.
+		EXPECT().
+		GetHomeDir().
+		Return(home, nil)
+	mock.EXPECT().RunCommand("npm", "config", "list")
+
+	err := p.createNpmrc()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := afero.ReadFile(fs, path.Join(home, ".npmrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf("%s//registry.npmjs.org/:_authToken=\"test-token\"\n//npm.corp.test.com/:_authToken=\"corp-token\"\n//npm.other.test.com/:_authToken=\"other-token\"\nregistry=https://registry.npmjs.org\n", npmrcDefaults)
+	if string(f) != want {
+		t.Errorf("%s != %s", f, want)
+	}
+}
+
+func TestPlugin_npmrcAuth_Fallback(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{
+		UserName: "testuser",
+		Password: "testpass",
+		Registry: "http://registry.test.com",
+	})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	auths, err := p.npmrcAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"//registry.test.com/:_auth=" + auth, "//npm.corp.test.com/:_auth=" + auth}
+	if fmt.Sprint(auths) != fmt.Sprint(want) {
+		t.Errorf("npmrcAuth = %v, want %v", auths, want)
+	}
+}
+
+func TestPlugin_publishSelected_PublishConfig(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces: true,
+		Tag:        "latest",
+		Registry:   "http://registry.test.com",
+	})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "@corp/internal", "versions", "--registry", "https://npm.corp.test.com/"})).
+		Return([]byte(`["0.1.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "@corp/public", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["0.1.0"]`), nil)
+
+	results, err := p.verifyPackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--tag", "next", "--provenance", "--workspace", "packages/internal", "--registry", "https://npm.corp.test.com/"})).
+		Return(nil, nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--tag", "latest", "--access", "public", "--workspace", "packages/public", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err = p.publishSelected(results)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfig_Validate_RegistryTokens(t *testing.T) {
+	c := &Config{
+		Token:          "test-token",
+		RegistryTokens: []string{"https://npm.corp.test.com"},
+	}
+	p, _, _ := createTestPlugin(t, c)
+
+	err := p.Validate()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPlugin_authenticate_PublishConfigRegistries(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		SkipPing: true,
+		Registry: "https://registry.npmjs.org",
+	})
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	mock.
+		EXPECT().
+		RunCommandString(gomock.Eq("npm"), gomock.Eq([]string{"whoami", "--registry", "https://registry.npmjs.org"})).
+		Return("testuser", nil)
+	mock.
+		EXPECT().
+		RunCommandString(gomock.Eq("npm"), gomock.Eq([]string{"whoami", "--registry", "https://npm.corp.test.com/"})).
+		Return("", errors.New("exit status 1"))
+
+	err := p.authenticate()
+	if err == nil || !strings.Contains(err.Error(), "https://npm.corp.test.com/") {
+		t.Errorf("authenticate() error = %v, want the publishConfig registry to fail", err)
+	}
+}
+
+func TestPlugin_authenticate_YarnScopes(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{})
+	p.manager = yarnManager
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	mock.
+		EXPECT().
+		RunCommandString(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "whoami"})).
+		Return("testuser", nil)
+	mock.
+		EXPECT().
+		RunCommandString(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "whoami", "--scope", "corp", "--publish"})).
+		Return("testuser", nil)
+
+	if err := p.authenticate(); err != nil {
+		t.Error(err)
+	}
+}
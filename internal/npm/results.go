@@ -0,0 +1,350 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	statusPending   = "pending"
+	statusPublished = "published"
+	statusDryRun    = "dry-run"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+)
+
+// packageResult tracks what happened to a single package during a release.
+type packageResult struct {
+	Dir     string
+	Name    string
+	Version string
+	Status  string
+	Err     error
+	Package packageJSON
+
+	// FirstPublish is set when the package is not in the registry yet.
+	FirstPublish bool
+
+	// publish details, set once the package was published
+	Tag       string
+	Access    string
+	Registry  string
+	Integrity string
+	Duration  time.Duration
+}
+
+// publishReport is the reported outcome of a single package, shared by every report output.
+type publishReport struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Tag       string `json:"tag,omitempty"`
+	Access    string `json:"access,omitempty"`
+	Registry  string `json:"registry,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+	Status    string `json:"status"`
+	Duration  string `json:"duration,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// String identifies the package by name@version, falling back to its directory.
+func (r packageResult) String() string {
+	if len(r.Name) == 0 {
+		return r.Dir
+	}
+
+	return r.Name + "@" + r.Version
+}
+
+// publishSelected publishes the packages with a new version one at a time,
+// passing each workspace to npm explicitly in dependency order.
+func (p *plugin) publishSelected(results []packageResult) error {
+	var pending []packageResult
+
+	for _, r := range results {
+		if r.Status == statusPending {
+			pending = append(pending, r)
+		}
+	}
+
+	if len(pending) == 0 {
+		log.Info("No new versions to publish")
+		logResults(results)
+
+		return resultsError(results)
+	}
+
+	ordered, err := publishOrder(pending)
+	if err != nil {
+		return err
+	}
+
+	order := make([]string, 0, len(ordered))
+	for _, r := range ordered {
+		order = append(order, r.Name)
+	}
+
+	log.WithFields(log.Fields{
+		"order": strings.Join(order, " -> "),
+	}).Info("Computed publish order")
+
+	if p.manager == pnpmManager {
+		p.publishPnpm(results)
+		logResults(results)
+
+		return resultsError(results)
+	}
+
+	graph := newDependencyGraph(pending)
+	failed := make(map[string]bool)
+	status := make(map[string]packageResult, len(ordered))
+
+	for _, r := range ordered {
+		// a package cannot be installed if a dependency failed to publish
+		if graph.dependsOn(r.Name, failed) {
+			r.Status, r.Err = statusFailed, errors.New("not published because a dependency failed to publish")
+			failed[r.Name] = true
+			status[r.Name] = r
+
+			continue
+		}
+
+		r = p.publishPackage(r)
+		if r.Status == statusFailed {
+			failed[r.Name] = true
+		}
+
+		status[r.Name] = r
+	}
+
+	for i, r := range results {
+		if updated, ok := status[r.Name]; ok && r.Status == statusPending {
+			results[i] = updated
+		}
+	}
+
+	logResults(results)
+
+	return resultsError(results)
+}
+
+// publishPackage publishes the root package or a single workspace, recording the outcome in the result.
+// https://docs.npmjs.com/cli/publish
+func (p *plugin) publishPackage(r packageResult) packageResult {
+	pc := r.Package.PublishConfig
+	r.Tag, r.Access, r.Registry = p.publishTag(pc), p.publishAccess(pc), pc.registry(p.config.Registry)
+
+	log.WithFields(log.Fields{"package": r.String()}).Info("Building publish command")
+
+	if p.manager == yarnManager {
+		return p.runPublish(r, "yarn", p.yarnPublishArgs(r))
+	}
+
+	args := append([]string{"publish", "--quiet"}, p.publishOptions(pc)...)
+
+	if r.Dir != "." {
+		log.Info("Publishing workspace " + r.Dir)
+
+		args = append(args, "--workspace", r.Dir)
+	}
+
+	args = append(args, "--registry", r.Registry)
+
+	return p.runPublish(r, "npm", args)
+}
+
+// runPublish runs the publish command and records how it went in the result.
+func (p *plugin) runPublish(r packageResult, name string, args []string) packageResult {
+	start := time.Now()
+	out, err := p.cli.RunCommandBytes(name, args...)
+	r.Duration = time.Since(start)
+
+	if err != nil {
+		r.Status, r.Err = statusFailed, fmt.Errorf("publish failed: %w", err)
+
+		return r
+	}
+
+	r.Status = p.publishedStatus()
+
+	if res, ok := parsePublishResponse(out, r.Name); ok {
+		r.Integrity = res.Integrity
+
+		if len(r.Name) == 0 {
+			r.Name, r.Version = res.Name, res.Version
+		}
+	} else {
+		log.Trace("Failed to convert npm publish response")
+	}
+
+	logPublished(r)
+
+	return r
+}
+
+// publishedStatus is the status of a package whose publish command succeeded,
+// nothing is published during a dry run.
+func (p *plugin) publishedStatus() string {
+	if p.config.DryRun {
+		return statusDryRun
+	}
+
+	return statusPublished
+}
+
+// logPublished logs a package whose publish command succeeded.
+func logPublished(r packageResult) {
+	entry := log.WithFields(log.Fields{
+		r.Name: r.Version,
+	})
+
+	if r.Status == statusDryRun {
+		entry.Info("Dry run of node package succeeded, nothing was published")
+
+		return
+	}
+
+	entry.Info("Successfully published node package!")
+}
+
+// parsePublishResponse reads the npm publish JSON output, which is keyed by
+// package name when a workspace is published.
+func parsePublishResponse(out []byte, name string) (publishResponse, bool) {
+	var res publishResponse
+	if err := json.Unmarshal(out, &res); err == nil && len(res.Name) > 0 {
+		return res, true
+	}
+
+	var workspaces workspacesPublishResponse
+	if err := json.Unmarshal(out, &workspaces); err != nil {
+		return res, false
+	}
+
+	res, ok := workspaces[name]
+
+	return res, ok
+}
+
+// auditWorkspaces returns the workspace directories of the results, the root
+// package is audited as a whole.
+func auditWorkspaces(results []packageResult) []string {
+	var dirs []string
+
+	for _, r := range results {
+		if r.Dir != "." {
+			dirs = append(dirs, r.Dir)
+		}
+	}
+
+	return dirs
+}
+
+// reportResults converts the results for the report outputs.
+func reportResults(results []packageResult) []publishReport {
+	reports := make([]publishReport, 0, len(results))
+
+	for _, r := range results {
+		report := publishReport{
+			Name:      r.Name,
+			Version:   r.Version,
+			Tag:       r.Tag,
+			Access:    r.Access,
+			Registry:  r.Registry,
+			Integrity: r.Integrity,
+			Status:    r.Status,
+		}
+
+		if len(report.Name) == 0 {
+			report.Name = r.Dir
+		}
+
+		if r.Duration > 0 {
+			report.Duration = r.Duration.Round(time.Millisecond).String()
+		}
+
+		if r.Err != nil {
+			report.Error = r.Err.Error()
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports
+}
+
+// resultsTable renders the reports as an aligned table, one line per row.
+func resultsTable(reports []publishReport) []string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tVERSION\tTAG\tACCESS\tREGISTRY\tINTEGRITY\tSTATUS\tDURATION")
+
+	for _, r := range reports {
+		fmt.Fprintln(w, strings.Join([]string{
+			r.Name, r.Version, orDash(r.Tag), orDash(r.Access), orDash(r.Registry), orDash(r.Integrity), r.Status, orDash(r.Duration),
+		}, "\t"))
+	}
+
+	w.Flush()
+
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+}
+
+// orDash fills empty table cells.
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+
+	return s
+}
+
+// logResults prints a table and a summary of the published, skipped and failed packages.
+func logResults(results []packageResult) {
+	summary := make(map[string][]string)
+
+	for _, r := range results {
+		summary[r.Status] = append(summary[r.Status], r.String())
+
+		if r.Err != nil {
+			log.WithFields(log.Fields{
+				"package": r.String(),
+			}).Warn(r.Err)
+		}
+	}
+
+	for _, line := range resultsTable(reportResults(results)) {
+		log.Info(line)
+	}
+
+	log.WithFields(log.Fields{
+		statusPublished: strings.Join(summary[statusPublished], ", "),
+		statusDryRun:    strings.Join(summary[statusDryRun], ", "),
+		statusSkipped:   strings.Join(summary[statusSkipped], ", "),
+		statusFailed:    strings.Join(summary[statusFailed], ", "),
+	}).Info("Release summary")
+}
+
+// resultsError returns an error naming every failed package.
+func resultsError(results []packageResult) error {
+	var failed []string
+
+	for _, r := range results {
+		if r.Status == statusFailed {
+			failed = append(failed, r.String())
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d package(s) failed: %s", len(failed), strings.Join(failed, ", "))
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+func TestPlugin_verifyPackages_SkipExisting(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces:   true,
+		SkipExisting: true,
+		Registry:     "http://registry.test.com",
+	})
+	writeTestWorkspaces(t, fs, `{"workspaces": ["packages/*"]}`, "packages/a", "packages/b", "packages/c")
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "packages/a", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["0.1.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "packages/b", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["1.0.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "packages/c", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"error": {"code": "E500", "summary": "Internal Server Error"}}`), errors.New("Process exited with status code 1"))
+
+	results, err := p.verifyPackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{statusPending, statusSkipped, statusFailed}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s status = %s, want %s", r.Dir, r.Status, want[i])
+		}
+	}
+}
+
+func TestPlugin_publishSelected(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Tag:      "beta",
+		Registry: "http://registry.test.com",
+	})
+	results := []packageResult{
+		{Dir: "packages/a", Name: "a", Version: "1.0.0", Status: statusPending},
+		{Dir: "packages/b", Name: "b", Version: "1.0.0", Status: statusSkipped},
+		{Dir: "packages/c", Name: "c", Version: "1.0.0", Status: statusPending},
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--tag", "beta", "--workspace", "packages/a", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--tag", "beta", "--workspace", "packages/c", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{}`), nil)
+
+	err := p.publishSelected(results)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if results[0].Status != statusPublished || results[1].Status != statusSkipped || results[2].Status != statusPublished {
+		t.Errorf("unexpected results %v", results)
+	}
+}
+
+func TestPlugin_publishSelected_Failed(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Registry: "http://registry.test.com",
+	})
+	results := []packageResult{
+		{Dir: "packages/a", Name: "a", Version: "1.0.0", Status: statusPending},
+		{Dir: "packages/b", Status: statusFailed, Err: errors.New("failed to verify package.json")},
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--workspace", "packages/a", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{}`), nil)
+
+	err := p.publishSelected(results)
+	if err == nil {
+		t.Error("failed packages should fail the release after publishing the rest")
+	}
+}
+
+func TestPlugin_publishSelected_NothingNew(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Registry: "http://registry.test.com",
+	})
+	results := []packageResult{
+		{Dir: ".", Name: "a", Version: "1.0.0", Status: statusSkipped},
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Any()).
+		Times(0)
+
+	err := p.publishSelected(results)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_publishPackage_Report(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Tag:      "beta",
+		Access:   "public",
+		Registry: "http://registry.test.com",
+	})
+	res := `{
+		"@corp/ui": {
+			"id": "@corp/ui@1.1.0",
+			"name": "@corp/ui",
+			"version": "1.1.0",
+			"integrity": "sha512-abc=="
+		}
+	}`
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "--quiet", "--tag", "beta", "--access", "public", "--workspace", "packages/ui", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+
+	r := p.publishPackage(packageResult{Dir: "packages/ui", Name: "@corp/ui", Version: "1.1.0", Status: statusPending})
+
+	reports := reportResults([]packageResult{r, {Dir: "packages/b", Status: statusFailed, Err: errors.New("failed to verify package.json")}})
+	if reports[0].Integrity != "sha512-abc==" || reports[0].Tag != "beta" || reports[0].Access != "public" || reports[0].Status != statusPublished {
+		t.Errorf("unexpected report %+v", reports[0])
+	}
+
+	if reports[1].Name != "packages/b" || reports[1].Error != "failed to verify package.json" {
+		t.Errorf("unexpected report %+v", reports[1])
+	}
+}
+
+func TestPlugin_resultsTable(t *testing.T) {
+	reports := []publishReport{
+		{Name: "@corp/tokens", Version: "1.2.0", Tag: "latest", Registry: "http://registry.test.com", Integrity: "sha512-abc==", Status: statusPublished, Duration: "1.2s"},
+		{Name: "b", Version: "1.0.0", Status: statusSkipped},
+	}
+
+	want := []string{
+		"NAME          VERSION  TAG     ACCESS  REGISTRY                  INTEGRITY     STATUS     DURATION",
+		"@corp/tokens  1.2.0    latest  -       http://registry.test.com  sha512-abc==  published  1.2s",
+		"b             1.0.0    -       -       -                         -             skipped    -",
+	}
+
+	table := resultsTable(reports)
+	if strings.Join(table, "\n") != strings.Join(want, "\n") {
+		t.Errorf("resultsTable =\n%s\nwant\n%s", strings.Join(table, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// tarballManifest is the location of package.json inside an npm tarball.
+const tarballManifest = "package/package.json"
+
+// maxManifestSize guards against decompressing an unreasonably large package.json.
+const maxManifestSize = 10 << 20
+
+// findTarballs resolves the tarball parameter to the matching files.
+func (p *plugin) findTarballs() ([]string, error) {
+	tarballs, err := afero.Glob(p.os.Fs, p.config.Tarball)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tarball pattern %s: %w", p.config.Tarball, err)
+	}
+
+	if len(tarballs) == 0 {
+		return nil, fmt.Errorf("no tarballs found matching %s", p.config.Tarball)
+	}
+
+	return tarballs, nil
+}
+
+// verifyTarballs validates the package.json and version of every matching tarball.
+func (p *plugin) verifyTarballs() error {
+	tarballs, err := p.findTarballs()
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"tarballs": tarballs,
+	}).Info("Found tarballs to publish")
+
+	for _, t := range tarballs {
+		np, err := p.readTarballPackage(t)
+		if err != nil {
+			return err
+		}
+
+		if err := np.Validate(); err != nil {
+			return fmt.Errorf("failed to verify %s: %w", t, err)
+		}
+
+		// tarballs are always published with npm
+		if err := p.checkProvenance(np); err != nil {
+			return err
+		}
+
+		if err := p.validatePackageVersion(np); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishTarballs publishes every matching tarball.
+func (p *plugin) publishTarballs() error {
+	tarballs, err := p.findTarballs()
+	if err != nil {
+		return err
+	}
+
+	results := make([]packageResult, 0, len(tarballs))
+
+	for _, t := range tarballs {
+		np, err := p.readTarballPackage(t)
+		if err != nil {
+			return err
+		}
+
+		r := p.publishTarball(packageResult{Dir: t, Name: np.Name, Version: np.Version, Package: np, Status: statusPending})
+		results = append(results, r)
+
+		// stop at the first failure like a regular publish
+		if r.Status == statusFailed {
+			break
+		}
+	}
+
+	logResults(results)
+
+	return resultsError(results)
+}
+
+// readTarballPackage reads the package.json embedded in a gzipped npm tarball.
+func (p *plugin) readTarballPackage(file string) (packageJSON, error) {
+	nodePackage := packageJSON{}
+
+	f, err := p.os.Open(file)
+	if err != nil {
+		return nodePackage, fmt.Errorf("failed to open tarball %s: %w", file, err)
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nodePackage, fmt.Errorf("failed to decompress tarball %s: %w", file, err)
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nodePackage, fmt.Errorf("%s not found in tarball %s", tarballManifest, file)
+		}
+
+		if err != nil {
+			return nodePackage, fmt.Errorf("failed to read tarball %s: %w", file, err)
+		}
+
+		if path.Clean(hdr.Name) != tarballManifest {
+			continue
+		}
+
+		b, err := io.ReadAll(io.LimitReader(tr, maxManifestSize))
+		if err != nil {
+			return nodePackage, fmt.Errorf("failed to read %s in tarball %s: %w", tarballManifest, file, err)
+		}
+
+		if err := json.Unmarshal(b, &nodePackage); err != nil {
+			return nodePackage, fmt.Errorf("failed to marshall package.json in tarball %s: %w", file, err)
+		}
+
+		log.WithFields(log.Fields{
+			"tarball": file,
+			"name":    nodePackage.Name,
+			"version": nodePackage.Version,
+		}).Debug("Read package.json from tarball")
+
+		return nodePackage, nil
+	}
+}
+
+// publishTarball publishes a prebuilt tarball, recording the outcome in the result.
+// https://docs.npmjs.com/cli/publish
+func (p *plugin) publishTarball(r packageResult) packageResult {
+	pc := r.Package.PublishConfig
+	r.Tag, r.Access, r.Registry = p.publishTag(pc), p.publishAccess(pc), pc.registry(p.config.Registry)
+
+	log.WithFields(log.Fields{"tarball": r.Dir}).Info("Building publish command")
+
+	args := append([]string{"publish", r.Dir, "--quiet"}, p.publishOptions(pc)...)
+	args = append(args, "--registry", r.Registry)
+
+	return p.runPublish(r, "npm", args)
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+)
+
+func writeTestTarball(t *testing.T, fs afero.Fs, file, name, contents string) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tw.Write([]byte(contents)); err != nil {
+		t.Fatal(err)
+	}
+
+	tw.Close()
+	gz.Close()
+
+	if err := afero.WriteFile(fs, file, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlugin_readTarballPackage(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestTarball(t, fs, "vela-npm-1.0.0.tgz", "package/package.json", `{"name": "vela-npm", "version": "1.0.0"}`)
+
+	np, err := p.readTarballPackage("vela-npm-1.0.0.tgz")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if np.Name != "vela-npm" || np.Version != "1.0.0" {
+		t.Errorf("unexpected package %v", np)
+	}
+}
+
+func TestPlugin_readTarballPackage_NoManifest(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestTarball(t, fs, "vela-npm-1.0.0.tgz", "package/index.js", `module.exports = {}`)
+
+	_, err := p.readTarballPackage("vela-npm-1.0.0.tgz")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPlugin_release_Tarball_Glob(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Tarball:    "dist/*.tgz",
+		AuditLevel: None,
+		Registry:   "http://registry.test.com",
+	})
+	writeTestTarball(t, fs, "dist/a-1.0.0.tgz", "package/package.json", `{"name": "a", "version": "1.0.0"}`)
+	writeTestTarball(t, fs, "dist/b-2.0.0.tgz", "package/package.json", `{"name": "b", "version": "2.0.0"}`)
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "a", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["0.1.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "b", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["1.0.0"]`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "dist/a-1.0.0.tgz", "--quiet", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"name": "a", "version": "1.0.0"}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"publish", "dist/b-2.0.0.tgz", "--quiet", "--registry", "http://registry.test.com"})).
+		Return([]byte(`{"name": "b", "version": "2.0.0"}`), nil)
+
+	err := p.release()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_release_Tarball_VersionConflict(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Tarball:  "a-1.0.0.tgz",
+		Registry: "http://registry.test.com",
+	})
+	writeTestTarball(t, fs, "a-1.0.0.tgz", "package/package.json", `{"name": "a", "version": "1.0.0"}`)
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "a", "versions", "--registry", "http://registry.test.com"})).
+		Return([]byte(`["1.0.0"]`), nil)
+
+	err := p.release()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPlugin_release_Tarball_NoMatches(t *testing.T) {
+	p, _, _ := createTestPlugin(t, &Config{
+		Tarball: "dist/*.tgz",
+	})
+
+	err := p.release()
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// unpublishWindow is how long after publishing npm allows a version to be unpublished.
+// https://docs.npmjs.com/policies/unpublish
+const unpublishWindow = 72 * time.Hour
+
+// unpublishPlugin removes published versions of a package.
+type unpublishPlugin struct {
+	*plugin
+}
+
+// Exec runs the unpublish plugin.
+func (p *unpublishPlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	return p.unpublish()
+}
+
+// unpublish removes a published version, range or entire package from the registry.
+// https://docs.npmjs.com/cli/commands/npm-unpublish
+func (p *plugin) unpublish() error {
+	name, version := splitPackageSpec(p.config.Package)
+
+	times, err := p.packageTimes(name)
+	if err != nil {
+		return err
+	}
+
+	versions, err := unpublishVersions(times, version)
+	if err != nil {
+		return fmt.Errorf("unable to unpublish %s: %w", p.config.Package, err)
+	}
+
+	for _, v := range versions {
+		published, err := time.Parse(time.RFC3339, times[v])
+		if err != nil {
+			log.Tracef("unable to parse publish time for %s@%s", name, v)
+
+			continue
+		}
+
+		if age := time.Since(published); age > unpublishWindow {
+			log.WithFields(log.Fields{
+				"package":   name,
+				"version":   v,
+				"published": times[v],
+			}).Warn("Version is past the npm unpublish window, the registry may reject it")
+		}
+	}
+
+	// log an audit trail of what is being removed and by whom
+	log.WithFields(log.Fields{
+		"package":  name,
+		"versions": strings.Join(versions, ", "),
+		"registry": p.config.Registry,
+		"username": p.config.UserName,
+		"repo":     os.Getenv("VELA_REPO_FULL_NAME"),
+		"build":    os.Getenv("VELA_BUILD_NUMBER"),
+		"author":   os.Getenv("VELA_BUILD_AUTHOR"),
+	}).Warn("Versions that will be unpublished")
+
+	if p.config.DryRun {
+		log.Info("Doing a dry run, nothing will be unpublished")
+
+		return nil
+	}
+
+	// an entire package is removed in a single command
+	if len(version) == 0 {
+		if _, err := p.cli.RunCommandBytes("npm", "unpublish", name, "--force", "--registry", p.config.Registry); err != nil {
+			return fmt.Errorf("failed to unpublish %s: %w", name, err)
+		}
+
+		log.WithFields(log.Fields{"package": name}).Info("Successfully unpublished package")
+
+		return nil
+	}
+
+	for _, v := range versions {
+		if _, err := p.cli.RunCommandBytes("npm", "unpublish", name+"@"+v, "--registry", p.config.Registry); err != nil {
+			return fmt.Errorf("failed to unpublish %s@%s: %w", name, v, err)
+		}
+
+		log.WithFields(log.Fields{"package": name, "version": v}).Info("Successfully unpublished version")
+	}
+
+	return nil
+}
+
+// packageTimes fetches the publish time of every version of a package from the registry.
+func (p *plugin) packageTimes(name string) (map[string]string, error) {
+	out, err := p.cli.RunCommandBytes("npm", "view", name, "time", "--json", "--registry", p.config.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time metadata for %s: %w", name, err)
+	}
+
+	times := make(map[string]string)
+	if err := json.Unmarshal(out, &times); err != nil {
+		return nil, fmt.Errorf("failed to convert npm time response: %w", err)
+	}
+
+	// created and modified are package level entries
+	delete(times, "created")
+	delete(times, "modified")
+
+	return times, nil
+}
+
+// unpublishVersions resolves which published versions the version, range or
+// empty string (entire package) refers to.
+func unpublishVersions(times map[string]string, version string) ([]string, error) {
+	if _, err := semver.StrictNewVersion(version); err == nil {
+		if _, ok := times[version]; !ok {
+			return nil, fmt.Errorf("version %s is not published", version)
+		}
+
+		return []string{version}, nil
+	}
+
+	var constraint *semver.Constraints
+
+	if len(version) > 0 {
+		c, err := semver.NewConstraint(version)
+		if err != nil {
+			return nil, err
+		}
+
+		constraint = c
+	}
+
+	var versions []*semver.Version
+
+	for v := range times {
+		sv, err := semver.NewVersion(v)
+		if err != nil {
+			continue
+		}
+
+		if constraint == nil || constraint.Check(sv) {
+			versions = append(versions, sv)
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no published versions match %s", version)
+	}
+
+	sort.Sort(semver.Collection(versions))
+
+	matches := make([]string, 0, len(versions))
+	for _, v := range versions {
+		matches = append(matches, v.Original())
+	}
+
+	return matches, nil
+}
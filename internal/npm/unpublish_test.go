@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+func TestPlugin_unpublish(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "@go-vela/vela-npm@1.1.0",
+		Registry: "http://registry.test.com",
+	})
+	res := fmt.Sprintf(`{
+		"created": "2020-01-01T00:00:00.000Z",
+		"modified": "%[1]s",
+		"1.0.0": "2020-01-01T00:00:00.000Z",
+		"1.1.0": "%[1]s"
+	}`, time.Now().UTC().Format(time.RFC3339))
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "@go-vela/vela-npm", "time", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"unpublish", "@go-vela/vela-npm@1.1.0", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err := p.unpublish()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_unpublish_DryRun(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "vela-npm@1.0.0",
+		DryRun:   true,
+		Registry: "http://registry.test.com",
+	})
+	res := `{"1.0.0": "2020-01-01T00:00:00.000Z"}`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "time", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Any()).
+		Times(0)
+
+	err := p.unpublish()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_unpublish_VersionNotPublished(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "vela-npm@2.0.0",
+		Registry: "http://registry.test.com",
+	})
+	res := `{"1.0.0": "2020-01-01T00:00:00.000Z"}`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "time", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+
+	err := p.unpublish()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPlugin_unpublish_EntirePackage(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{
+		Package:  "vela-npm",
+		Confirm:  "vela-npm",
+		Registry: "http://registry.test.com",
+	})
+	res := `{"1.0.0": "2020-01-01T00:00:00.000Z", "1.1.0": "2020-01-02T00:00:00.000Z"}`
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"view", "vela-npm", "time", "--json", "--registry", "http://registry.test.com"})).
+		Return([]byte(res), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("npm"), gomock.Eq([]string{"unpublish", "vela-npm", "--force", "--registry", "http://registry.test.com"})).
+		Return(nil, nil)
+
+	err := p.unpublish()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnpublishVersions_Range(t *testing.T) {
+	times := map[string]string{
+		"1.0.0": "2020-01-01T00:00:00.000Z",
+		"1.2.0": "2020-01-03T00:00:00.000Z",
+		"1.1.0": "2020-01-02T00:00:00.000Z",
+		"2.0.0": "2020-01-04T00:00:00.000Z",
+	}
+
+	versions, err := unpublishVersions(times, "^1.1.0")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(versions) != 2 || versions[0] != "1.1.0" || versions[1] != "1.2.0" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+}
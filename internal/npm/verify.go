@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// verifyPlugin runs the pre-publish checks without publishing.
+type verifyPlugin struct {
+	*plugin
+}
+
+// Exec runs the verify plugin.
+func (p *verifyPlugin) Exec() error {
+	if err := p.setup(true); err != nil {
+		return err
+	}
+
+	results, err := p.verifyRelease()
+	if err != nil {
+		return err
+	}
+
+	if p.config.SkipExisting {
+		logResults(results)
+
+		if err := resultsError(results); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Successfully verified node package!")
+
+	return nil
+}
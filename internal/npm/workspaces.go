@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// resolveWorkspaces expands workspace patterns, including ** and !negations,
+// to the directories containing a package.json.
+// https://docs.npmjs.com/cli/using-npm/workspaces
+func (p *plugin) resolveWorkspaces(patterns []string) ([]string, error) {
+	var include, exclude []string
+
+	for _, pattern := range patterns {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclude = append(exclude, cleanPattern(negated))
+		} else {
+			include = append(include, cleanPattern(pattern))
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	var dirs []string
+
+	for _, pattern := range include {
+		matches, err := p.globDirs(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			log.WithFields(log.Fields{"pattern": pattern}).Warn("Workspace pattern matched no packages")
+		}
+
+		for _, m := range matches {
+			if seen[m] || matchAny(exclude, m) {
+				continue
+			}
+
+			seen[m] = true
+			dirs = append(dirs, m)
+		}
+	}
+
+	return dirs, nil
+}
+
+// skipPrivateWorkspaces drops the workspaces marked private, which npm never publishes.
+func (p *plugin) skipPrivateWorkspaces(dirs []string) ([]string, error) {
+	var public, private []string
+
+	for _, d := range dirs {
+		np, err := p.readPackage(d)
+		if err != nil {
+			return nil, err
+		}
+
+		if np.Private {
+			private = append(private, d)
+
+			continue
+		}
+
+		public = append(public, d)
+	}
+
+	if len(private) > 0 {
+		log.WithFields(log.Fields{
+			"workspaces": strings.Join(private, ", "),
+		}).Info("Skipping private workspaces")
+	}
+
+	return public, nil
+}
+
+// globDirs walks the file system for package directories matching the pattern.
+func (p *plugin) globDirs(pattern string) ([]string, error) {
+	// only walk the part of the tree the pattern can match
+	root := "."
+
+	for _, seg := range strings.Split(pattern, "/") {
+		if hasMeta(seg) {
+			break
+		}
+
+		root = path.Join(root, seg)
+	}
+
+	if ok, _ := p.os.DirExists(root); !ok {
+		return nil, nil
+	}
+
+	var matches []string
+
+	err := afero.Walk(p.os.Fs, root, func(file string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		dir := filepath.ToSlash(file)
+
+		// dependencies and hidden directories are never workspaces
+		if dir != root && (info.Name() == "node_modules" || strings.HasPrefix(info.Name(), ".")) {
+			return filepath.SkipDir
+		}
+
+		// the root package is never its own workspace
+		if dir == "." || !matchPath(pattern, dir) {
+			return nil
+		}
+
+		if ok, _ := p.os.Exists(path.Join(dir, "package.json")); ok {
+			matches = append(matches, dir)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+// cleanPattern normalizes a workspace pattern to a slash separated relative path.
+func cleanPattern(pattern string) string {
+	return strings.TrimSuffix(path.Clean(filepath.ToSlash(strings.TrimSpace(pattern))), "/")
+}
+
+// hasMeta reports whether the path segment contains glob syntax.
+func hasMeta(seg string) bool {
+	return strings.ContainsAny(seg, `*?[\`)
+}
+
+// matchAny reports whether the path matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPath matches a slash separated path against a glob pattern where **
+// matches any number of directories.
+func matchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path.Clean(name), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// ** matches zero or more segments
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func writeTestWorkspaces(t *testing.T, fs afero.Fs, root string, dirs ...string) {
+	if err := afero.WriteFile(fs, "package.json", []byte(root), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range dirs {
+		if err := afero.WriteFile(fs, d+"/package.json", []byte(`{"name": "`+d+`", "version": "1.0.0"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeTestPackages writes the root package.json and the package.json of every directory.
+func writeTestPackages(t *testing.T, fs afero.Fs, root string, pkgs map[string]string) {
+	writeTestWorkspaces(t, fs, root)
+
+	for d, pkg := range pkgs {
+		if err := afero.WriteFile(fs, d+"/package.json", []byte(pkg), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlugin_checkForWorkspaces_Glob(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestWorkspaces(t, fs, `{"workspaces": ["packages/*", "tools/**", "!tools/internal/**"]}`,
+		"packages/b", "packages/a", "tools/lint", "tools/nested/build", "tools/internal/secret")
+
+	// directories without a package.json and dependencies are not workspaces
+	fs.MkdirAll("packages/empty", 0755) //nolint:errcheck // testing
+
+	err := afero.WriteFile(fs, "tools/lint/node_modules/dep/package.json", []byte(`{}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workspaces, err := p.checkForWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"packages/a", "packages/b", "tools/lint", "tools/nested/build"}
+	if !reflect.DeepEqual(workspaces, want) {
+		t.Errorf("checkForWorkspaces = %v, want %v", workspaces, want)
+	}
+}
+
+func TestPlugin_checkForWorkspaces_Object(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestWorkspaces(t, fs, `{"workspaces": {"packages": ["./example-1", "example-2"], "nohoist": ["**/react"]}}`,
+		"example-1", "example-2")
+
+	workspaces, err := p.checkForWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"example-1", "example-2"}
+	if !reflect.DeepEqual(workspaces, want) {
+		t.Errorf("checkForWorkspaces = %v, want %v", workspaces, want)
+	}
+}
+
+func TestPlugin_checkForWorkspaces_None(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestWorkspaces(t, fs, `{"name": "vela-npm"}`)
+
+	workspaces, err := p.checkForWorkspaces()
+	if err != nil || len(workspaces) != 0 {
+		t.Errorf("checkForWorkspaces = %v, %v", workspaces, err)
+	}
+}
+
+func TestPlugin_checkForWorkspaces_Invalid(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestWorkspaces(t, fs, `{"workspaces": "packages/*"}`)
+
+	_, err := p.checkForWorkspaces()
+	if err == nil {
+		t.Error("invalid workspaces should not be treated as no workspaces")
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"packages/*", "packages/a", true},
+		{"packages/*", "packages/a/b", false},
+		{"packages/**", "packages/a/b", true},
+		{"**/ui", "packages/web/ui", true},
+		{"**", "packages", true},
+		{"packages/@corp-*", "packages/@corp-ui", true},
+		{"example-1", "example-2", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchPath(%s, %s) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlugin_checkForWorkspaces_Private(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{Workspaces: true})
+	writeTestWorkspaces(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, "packages/a", "packages/c")
+
+	err := afero.WriteFile(fs, "packages/b/package.json", []byte(`{"name": "b", "version": "1.0.0", "private": true}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workspaces, err := p.checkForWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"packages/a", "packages/c"}
+	if !reflect.DeepEqual(workspaces, want) {
+		t.Errorf("checkForWorkspaces = %v, want %v", workspaces, want)
+	}
+}
+
+func TestPlugin_verifyPackages_PrivateRoot(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+
+	err := afero.WriteFile(fs, "package.json", []byte(`{"name": "tools", "version": "1.0.0", "private": true}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.verifyPackages()
+	if err == nil || !strings.Contains(err.Error(), "private") {
+		t.Errorf("verifyPackages should have failed for a private root package, got %v", err)
+	}
+}
+
+func TestPlugin_verifyPackages_NoWorkspacesDeclared(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{Workspaces: true})
+
+	err := afero.WriteFile(fs, "package.json", []byte(`{"name": "app", "version": "1.0.0"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.verifyPackages()
+	if err == nil || !strings.Contains(err.Error(), "none are declared") {
+		t.Errorf("verifyPackages should have failed without declared workspaces, got %v", err)
+	}
+}
+
+func TestPlugin_declaredWorkspaces_Resolved(t *testing.T) {
+	p, _, fs := createTestPlugin(t, &Config{})
+	writeTestWorkspaces(t, fs, `{"workspaces": ["packages/*"]}`, "packages/a", "packages/b")
+
+	first, err := p.declaredWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// later lookups reuse the workspaces resolved by the first one
+	if err := afero.WriteFile(fs, "package.json", []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := p.declaredWorkspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(first, second) || len(second) != 2 {
+		t.Errorf("declaredWorkspaces() = %v, want %v", second, first)
+	}
+}
@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// yarnrcFile is the Yarn Berry configuration file.
+// https://yarnpkg.com/configuration/yarnrc
+const yarnrcFile = ".yarnrc.yml"
+
+// verifyYarn makes sure the yarn command exists.
+func (p *plugin) verifyYarn() error {
+	o, err := p.cli.RunCommandString("yarn", "--version")
+	if err != nil {
+		return fmt.Errorf("yarn is not available, enable it with corepack: %w", err)
+	}
+
+	version := strings.TrimSpace(o)
+
+	log.WithFields(log.Fields{
+		"yarn": version,
+	}).Info("Verifying yarn command")
+
+	// Yarn Classic has no yarn npm commands to check, audit and publish with
+	if v, err := semver.NewVersion(version); err == nil && v.Major() < 2 {
+		return fmt.Errorf("yarn %s is Yarn Classic, Yarn 2 or newer is required, enable it with corepack and a packageManager field in package.json", version)
+	}
+
+	return nil
+}
+
+// yarnAuthOnly reports whether every registry command runs through yarn, which
+// reads its auth from .yarnrc.yml, the other actions and tarballs still use npm.
+func (p *plugin) yarnAuthOnly() bool {
+	if p.manager != yarnManager || len(p.config.Tarball) > 0 {
+		return false
+	}
+
+	switch p.config.Action {
+	case "", PublishAction, VerifyAction:
+		return true
+	default:
+		return false
+	}
+}
+
+// createYarnrc merges the registry auth into the user's .yarnrc.yml, which
+// Yarn Berry reads in addition to the project's configuration.
+func (p *plugin) createYarnrc() error {
+	log.Trace("Creating .yarnrc.yml...")
+
+	// set default home directory for root user
+	home := "/root"
+
+	if hd, err := p.cli.GetHomeDir(); err == nil {
+		home = hd
+	}
+
+	fp := filepath.Join(home, yarnrcFile)
+
+	log.WithFields(log.Fields{
+		"path": fp,
+	}).Info("Creating .yarnrc.yml configuration file")
+
+	rc := make(map[string]any)
+
+	if b, err := p.os.ReadFile(fp); err == nil {
+		if err := yaml.Unmarshal(b, &rc); err != nil {
+			return fmt.Errorf("failed to read existing %s: %w", fp, err)
+		}
+	}
+
+	creds, err := p.registryCredentials()
+	if err != nil {
+		return err
+	}
+
+	registries := yamlMap(rc["npmRegistries"])
+
+	for _, c := range creds {
+		prefix, err := registryPrefix(c.Registry)
+		if err != nil {
+			return err
+		}
+
+		entry := yamlMap(registries[prefix])
+
+		switch {
+		case len(c.Token) != 0:
+			entry["npmAuthToken"] = c.Token
+		case len(c.UserName) != 0:
+			entry["npmAuthIdent"] = c.UserName + ":" + c.Password
+		}
+
+		// if always-auth is given, write what is given to config, else rely on yarn to default (false)
+		if p.config.IsAlwaysAuthSet {
+			entry["npmAlwaysAuth"] = p.config.AlwaysAuth
+		}
+
+		if len(entry) > 0 {
+			registries[prefix] = entry
+		}
+	}
+
+	rc["npmRegistries"] = registries
+
+	if len(p.config.Registry) != 0 {
+		rc["npmRegistryServer"] = p.config.Registry
+	}
+
+	// scoped packages publishing to their own registry
+	if scopes := p.yarnScopes(yamlMap(rc["npmScopes"])); len(scopes) > 0 {
+		rc["npmScopes"] = scopes
+	}
+
+	// if strict-ssl is given, write what is given to config, else will rely on yarn to default (true)
+	if p.config.IsStrictSSLSet {
+		rc["enableStrictSsl"] = p.config.StrictSSL
+	}
+
+	rc["enableColors"] = false
+	rc["enableProgressBars"] = false
+	rc["enableTelemetry"] = false
+
+	b, err := yaml.Marshal(rc)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", yarnrcFile, err)
+	}
+
+	if err := p.os.MkdirAll(home, 0777); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := p.os.WriteFile(fp, b, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", fp, err)
+	}
+
+	log.Trace("... .yarnrc.yml successfully written")
+
+	return nil
+}
+
+// yarnScopes adds the publishConfig registry of every scoped package to the npmScopes
+// settings, yarn npm info reads the registry server so the version check asks the
+// registry the package is published to.
+func (p *plugin) yarnScopes(scopes map[string]any) map[string]any {
+	for scope, registry := range p.publishScopes() {
+		entry := yamlMap(scopes[scope])
+		entry["npmPublishRegistry"] = registry
+		entry["npmRegistryServer"] = registry
+		scopes[scope] = entry
+	}
+
+	return scopes
+}
+
+// publishScopes returns the publishConfig registry of every scoped package, keyed by scope without the @.
+func (p *plugin) publishScopes() map[string]string {
+	scopes := make(map[string]string)
+
+	workspaces, err := p.declaredWorkspaces()
+	if err != nil {
+		return scopes
+	}
+
+	for _, d := range append([]string{"."}, workspaces...) {
+		np, err := p.readPackage(d)
+		if err != nil || len(np.PublishConfig.Registry) == 0 || !strings.HasPrefix(np.Name, "@") {
+			continue
+		}
+
+		scopes[strings.TrimPrefix(strings.SplitN(np.Name, "/", 2)[0], "@")] = np.PublishConfig.Registry
+	}
+
+	return scopes
+}
+
+// yamlMap returns the YAML mapping, or a new one when the value is not a mapping.
+func yamlMap(v any) map[string]any {
+	if m, ok := v.(map[string]any); ok {
+		return m
+	}
+
+	return make(map[string]any)
+}
+
+// yarnPackageVersions fetches all published versions of a package with yarn npm info.
+// https://yarnpkg.com/cli/npm/info
+func (p *plugin) yarnPackageVersions(name string) ([]string, error) {
+	out, err := p.cli.RunCommandBytes("yarn", "npm", "info", name, "--fields", "versions", "--json")
+	if err != nil {
+		// yarn reports the registry response code instead of an npm error code
+		if strings.Contains(string(out), "404") {
+			return nil, errPackageNotFound
+		}
+
+		return nil, fmt.Errorf("yarn npm info %s failed: %w", name, err)
+	}
+
+	var info struct {
+		Versions []string `json:"versions"`
+	}
+
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("failed to convert yarn npm info response: %w", err)
+	}
+
+	return info.Versions, nil
+}
+
+// yarnPublishArgs builds the yarn npm publish command for the root package or
+// a single workspace, which yarn publishes to its publishConfig registry.
+// https://yarnpkg.com/cli/npm/publish
+func (p *plugin) yarnPublishArgs(r packageResult) []string {
+	var args []string
+
+	if r.Dir != "." {
+		log.Info("Publishing workspace " + r.Dir)
+
+		// https://yarnpkg.com/cli/workspaces/foreach
+		args = append(args, "workspaces", "foreach", "--all", "--include", r.Name)
+	}
+
+	// yarn npm publish has no dry run, packing shows what would be published
+	// https://yarnpkg.com/cli/pack
+	if p.config.DryRun {
+		log.Info("Doing a dry run")
+
+		return append(args, "pack", "--dry-run")
+	}
+
+	args = append(args, "npm", "publish")
+
+	if tag := p.publishTag(r.Package.PublishConfig); len(tag) != 0 {
+		log.WithFields(log.Fields{"tag": tag}).Info("Tagging package")
+
+		args = append(args, "--tag", tag)
+	}
+
+	if access := p.publishAccess(r.Package.PublishConfig); len(access) != 0 {
+		log.WithFields(log.Fields{"access": access}).Info("Setting package access")
+
+		args = append(args, "--access", access)
+	}
+
+	if r.Package.PublishConfig.Provenance {
+		log.Info("Publishing with provenance")
+
+		args = append(args, "--provenance")
+	}
+
+	return args
+}
+
+// yarnAudit runs yarn npm audit for the production dependencies of the root
+// package, or of the released workspaces only, leaving out private ones.
+// https://yarnpkg.com/cli/npm/audit
+func (p *plugin) yarnAudit(workspaces []string) error {
+	log.Info("Running audit check")
+
+	var args []string
+
+	if len(workspaces) > 0 {
+		// https://yarnpkg.com/cli/workspaces/foreach
+		args = append(args, "workspaces", "foreach", "--all")
+
+		for _, w := range workspaces {
+			np, err := p.readPackage(w)
+			if err != nil {
+				return err
+			}
+
+			args = append(args, "--include", np.Name)
+		}
+	}
+
+	args = append(args, "npm", "audit", "--recursive", "--environment", "production", "--severity", p.config.AuditLevel)
+
+	if _, err := p.cli.RunCommandBytes("yarn", args...); err != nil {
+		log.Trace(fmt.Errorf("audit command failed: %w", err))
+
+		return fmt.Errorf("audit failed for audit-level=%s, run `yarn %s` to view vulnerabilities that need fixed",
+			p.config.AuditLevel, strings.Join(args, " "))
+	}
+
+	return nil
+}
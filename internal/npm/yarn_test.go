@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: Apache-2.0
+package npm
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPlugin_createYarnrc(t *testing.T) {
+	c := &Config{
+		Token:           "test-token",
+		Registry:        "https://registry.npmjs.org",
+		RegistryTokens:  []string{"https://npm.corp.test.com=corp-token"},
+		AlwaysAuth:      true,
+		IsAlwaysAuthSet: true,
+	}
+	p, mock, fs := createTestPlugin(t, c)
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	home := path.Join("usr", "mctestface")
+	fs.MkdirAll(home, 0755) //nolint:errcheck // testing
+
+	existing := "npmRegistries:\n  //npm.existing.test.com:\n    npmAuthToken: existing-token\npnpMode: loose\n"
+	if err := afero.WriteFile(fs, path.Join(home, yarnrcFile), []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.
+		EXPECT().
+		GetHomeDir().
+		Return(home, nil)
+
+	if err := p.createYarnrc(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := afero.ReadFile(fs, path.Join(home, yarnrcFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rc struct {
+		PnpMode           string `yaml:"pnpMode"`
+		NpmRegistryServer string `yaml:"npmRegistryServer"`
+		NpmRegistries     map[string]struct {
+			NpmAuthToken  string `yaml:"npmAuthToken"`
+			NpmAlwaysAuth bool   `yaml:"npmAlwaysAuth"`
+		} `yaml:"npmRegistries"`
+		NpmScopes map[string]struct {
+			NpmPublishRegistry string `yaml:"npmPublishRegistry"`
+			NpmRegistryServer  string `yaml:"npmRegistryServer"`
+		} `yaml:"npmScopes"`
+	}
+
+	if err := yaml.Unmarshal(b, &rc); err != nil {
+		t.Fatal(err)
+	}
+
+	if rc.PnpMode != "loose" {
+		t.Errorf("existing setting was not kept: %s", b)
+	}
+
+	if rc.NpmRegistryServer != c.Registry {
+		t.Errorf("npmRegistryServer = %s, want %s", rc.NpmRegistryServer, c.Registry)
+	}
+
+	tokens := map[string]string{
+		"//npm.existing.test.com": "existing-token",
+		"//registry.npmjs.org":    "test-token",
+		"//npm.corp.test.com/":    "corp-token",
+	}
+	for registry, token := range tokens {
+		if got := rc.NpmRegistries[registry].NpmAuthToken; got != token {
+			t.Errorf("npmAuthToken of %s = %s, want %s", registry, got, token)
+		}
+	}
+
+	if !rc.NpmRegistries["//npm.corp.test.com/"].NpmAlwaysAuth {
+		t.Errorf("npmAlwaysAuth was not set: %s", b)
+	}
+
+	if got := rc.NpmScopes["corp"].NpmPublishRegistry; got != "https://npm.corp.test.com/" {
+		t.Errorf("npmPublishRegistry of corp scope = %s", got)
+	}
+
+	if got := rc.NpmScopes["corp"].NpmRegistryServer; got != "https://npm.corp.test.com/" {
+		t.Errorf("npmRegistryServer of corp scope = %s", got)
+	}
+}
+
+func TestPlugin_publishSelected_YarnPublishConfig(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{
+		Workspaces: true,
+		Tag:        "latest",
+		Registry:   "http://registry.test.com",
+	})
+	p.manager = yarnManager
+	writeTestPackages(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, testRegistryWorkspaces)
+
+	home := path.Join("usr", "mctestface")
+	fs.MkdirAll(home, 0755) //nolint:errcheck // testing
+	mock.
+		EXPECT().
+		GetHomeDir().
+		Return(home, nil)
+
+	if err := p.createYarnrc(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := afero.ReadFile(fs, path.Join(home, yarnrcFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rc struct {
+		NpmRegistryServer string `yaml:"npmRegistryServer"`
+		NpmScopes         map[string]struct {
+			NpmRegistryServer string `yaml:"npmRegistryServer"`
+		} `yaml:"npmScopes"`
+	}
+
+	if err := yaml.Unmarshal(b, &rc); err != nil {
+		t.Fatal(err)
+	}
+
+	// yarn npm info asks the scope registry for @corp packages and the default registry otherwise
+	if rc.NpmRegistryServer != "http://registry.test.com" || rc.NpmScopes["corp"].NpmRegistryServer != "https://npm.corp.test.com/" {
+		t.Errorf("version checks do not use the publishConfig registry: %s", b)
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "info", "@corp/internal", "--fields", "versions", "--json"})).
+		Return([]byte(`{"versions":["0.1.0"]}`), nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "info", "@corp/public", "--fields", "versions", "--json"})).
+		Return([]byte(`{"versions":["0.1.0"]}`), nil)
+
+	results, err := p.verifyPackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{
+			"workspaces", "foreach", "--all", "--include", "@corp/internal", "npm", "publish", "--tag", "next", "--provenance",
+		})).
+		Return([]byte{}, nil)
+	mock.
+		EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{
+			"workspaces", "foreach", "--all", "--include", "@corp/public", "npm", "publish", "--tag", "latest", "--access", "public",
+		})).
+		Return([]byte{}, nil)
+
+	if err := p.publishSelected(results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_yarnPackageVersions(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{})
+	p.manager = yarnManager
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "info", "test", "--fields", "versions", "--json"})).
+		Return([]byte(`{"versions":["1.0.0","1.1.0"]}`), nil)
+
+	if _, err := p.checkPackageVersion(packageJSON{Name: "test", Version: "1.1.0"}); !errors.Is(err, errVersionExists) {
+		t.Errorf("checkPackageVersion() error = %v, want %v", err, errVersionExists)
+	}
+}
+
+func TestPlugin_yarnPackageVersions_NotFound(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{})
+	p.manager = yarnManager
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "info", "test", "--fields", "versions", "--json"})).
+		Return([]byte("Response Code: 404 (Not Found)"), errors.New("exit status 1"))
+
+	firstPublish, err := p.checkPackageVersion(packageJSON{Name: "test", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !firstPublish {
+		t.Error("package should be published for the first time")
+	}
+}
+
+func TestPlugin_publishPackage_Yarn(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{Registry: "https://registry.npmjs.org", Tag: "next"})
+	p.manager = yarnManager
+
+	gomock.InOrder(
+		mock.EXPECT().
+			RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "publish", "--tag", "next"})).
+			Return([]byte{}, nil),
+		mock.EXPECT().
+			RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{
+				"workspaces", "foreach", "--all", "--include", "@corp/internal", "npm", "publish", "--tag", "beta", "--access", "restricted",
+			})).
+			Return([]byte{}, nil),
+	)
+
+	root := p.publishPackage(packageResult{Dir: ".", Name: "root", Version: "1.0.0"})
+	if root.Status != statusPublished {
+		t.Errorf("root status = %s, %v", root.Status, root.Err)
+	}
+
+	workspace := p.publishPackage(packageResult{
+		Dir:     "packages/internal",
+		Name:    "@corp/internal",
+		Version: "1.0.0",
+		Package: packageJSON{PublishConfig: publishConfig{Tag: "beta", Access: "restricted"}},
+	})
+	if workspace.Status != statusPublished {
+		t.Errorf("workspace status = %s, %v", workspace.Status, workspace.Err)
+	}
+}
+
+func TestPlugin_publishPackage_YarnDryRun(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{DryRun: true})
+	p.manager = yarnManager
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"pack", "--dry-run"})).
+		Return([]byte{}, nil)
+
+	if r := p.publishPackage(packageResult{Dir: ".", Name: "root", Version: "1.0.0"}); r.Status != statusDryRun {
+		t.Errorf("status = %s, %v", r.Status, r.Err)
+	}
+}
+
+func TestPlugin_audit_Yarn(t *testing.T) {
+	p, mock, fs := createTestPlugin(t, &Config{AuditLevel: "high"})
+	p.manager = yarnManager
+	writeTestWorkspaces(t, fs, `{"private": true, "workspaces": ["packages/*"]}`, "packages/a", "packages/private")
+
+	// only the released workspace is audited
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{
+			"workspaces", "foreach", "--all", "--include", "packages/a", "npm", "audit", "--recursive", "--environment", "production", "--severity", "high",
+		})).
+		Return([]byte{}, errors.New("exit status 1"))
+
+	err := p.audit([]string{"packages/a"})
+	if err == nil || !strings.Contains(err.Error(), "yarn workspaces foreach --all --include packages/a npm audit") {
+		t.Errorf("audit should fail with the command to reproduce it, got %v", err)
+	}
+}
+
+func TestPlugin_audit_YarnRoot(t *testing.T) {
+	p, mock, _ := createTestPlugin(t, &Config{AuditLevel: "high"})
+	p.manager = yarnManager
+
+	mock.EXPECT().
+		RunCommandBytes(gomock.Eq("yarn"), gomock.Eq([]string{"npm", "audit", "--recursive", "--environment", "production", "--severity", "high"})).
+		Return([]byte{}, nil)
+
+	if err := p.audit(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlugin_yarnAuthOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		manager string
+		config  Config
+		want    bool
+	}{
+		{name: "publish", manager: yarnManager, config: Config{Action: PublishAction}, want: true},
+		{name: "verify", manager: yarnManager, config: Config{Action: VerifyAction}, want: true},
+		{name: "dist-tag", manager: yarnManager, config: Config{Action: DistTagAction}},
+		{name: "tarball", manager: yarnManager, config: Config{Action: PublishAction, Tarball: "dist/*.tgz"}},
+		{name: "npm", manager: npmManager, config: Config{Action: PublishAction}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, _ := createTestPlugin(t, &tt.config)
+			p.manager = tt.manager
+
+			if got := p.yarnAuthOnly(); got != tt.want {
+				t.Errorf("yarnAuthOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlugin_verifyYarn(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "4.1.0\n"},
+		{version: "2.4.3\n"},
+		{version: "1.22.19\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.version), func(t *testing.T) {
+			p, mock, _ := createTestPlugin(t, &Config{})
+
+			mock.EXPECT().RunCommandString("yarn", "--version").Return(tt.version, nil)
+
+			err := p.verifyYarn()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyYarn() error = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), "corepack") {
+				t.Errorf("verifyYarn() error = %v, want a corepack hint", err)
+			}
+		})
+	}
+}